@@ -0,0 +1,86 @@
+// Command docxcompare reports the differences between two .docx files and
+// optionally writes them as a redline document with tracked changes.
+//
+// Usage:
+//
+//	docxcompare [-json] [-redline out.docx] [-author name] old.docx new.docx
+//
+// The exit status is 0 when the documents are equal, 1 when they differ and
+// 2 on errors, like diff.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"zdocx/zdocx"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the differences as JSON")
+	redline := flag.String("redline", "", "write a redline .docx to this file")
+	author := flag.String("author", "docxcompare", "author of the tracked changes in the redline")
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: docxcompare [-json] [-redline out.docx] [-author name] old.docx new.docx")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	comparison, err := zdocx.CompareFiles(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fail(err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(comparison.Differences); err != nil {
+			fail(err)
+		}
+	} else {
+		for _, i := range comparison.Differences {
+			fmt.Printf("%s %s %s", i.Change, i.Kind, i.Path)
+
+			if i.Before != "" || i.After != "" {
+				fmt.Printf(": %q -> %q", i.Before, i.After)
+			}
+
+			fmt.Println()
+		}
+	}
+
+	if *redline != "" {
+		buf, err := comparison.Redline(zdocx.RedlineArgs{
+			Author: *author,
+			Date:   time.Now(),
+		})
+		if err != nil {
+			fail(err)
+		}
+
+		if err := ioutil.WriteFile(*redline, buf.Bytes(), 0644); err != nil {
+			fail(err)
+		}
+	}
+
+	if !comparison.IsEqual() {
+		os.Exit(1)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "docxcompare:", err)
+	os.Exit(2)
+}
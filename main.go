@@ -1,21 +1,16 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"time"
 	"zdocx/zdocx"
-
-	"github.com/wcharczuk/go-chart"
-	"github.com/wcharczuk/go-chart/drawing"
 )
 
 func main() {
 	doc := zdocx.NewDocument(zdocx.NewDocumentArgs{})
-	doc.PageOrientation = zdocx.PageOrientationAlbum
+	doc.PageOrientation = zdocx.PageOrientationLandscape
+	doc.PageSize = zdocx.PageSizeA4()
 
 	doc.Header = []*zdocx.Paragraph{
 		{
@@ -35,6 +30,29 @@ func main() {
 				},
 			},
 		},
+		{
+			Style: zdocx.PStyle{
+				HorisontalAlign: zdocx.HorisontalAlignRight,
+			},
+			Texts: []*zdocx.Text{
+				{
+					Text: "Page",
+				},
+				{
+					Field: &zdocx.Field{
+						Type: zdocx.FieldTypePage,
+					},
+				},
+				{
+					Text: "of",
+				},
+				{
+					Field: &zdocx.Field{
+						Type: zdocx.FieldTypeNumPages,
+					},
+				},
+			},
+		},
 	}
 
 	if err := doc.SetP(&zdocx.Paragraph{
@@ -337,6 +355,7 @@ func main() {
 					FileName: file.Name(),
 					Bytes:    imageBytes,
 					Width:    50,
+					SizeUnit: zdocx.SizeUnitMM,
 				},
 			},
 		},
@@ -344,94 +363,31 @@ func main() {
 		panic(err)
 	}
 
-	gridColor := drawing.Color{R: 123, G: 156, B: 230, A: 1}.WithAlpha(80)
-	gridWidth := 0.5
-	graph := chart.Chart{
-		Background: chart.Style{
-			Padding: chart.Box{
-				Top:    10,
-				Left:   20,
-				Right:  20,
-				Bottom: 10,
-			},
-		},
-		Height: 300,
-		Width:  1200,
-		YAxisSecondary: chart.YAxis{
-			Style: chart.Style{
-				Hidden: true,
-			},
-		},
-		XAxis: chart.XAxis{
-			Style: chart.Style{
-				StrokeWidth: 0,
-				StrokeColor: gridColor,
-				FontColor:   drawing.ColorFromHex("9e9e9e"),
-				DotWidth:    0,
-			},
-		},
-		YAxis: chart.YAxis{
-			AxisType: chart.YAxisSecondary,
-			Style: chart.Style{
-				StrokeColor: drawing.ColorFromHex("ffffff"),
-				FontColor:   drawing.ColorFromHex("9e9e9e"),
-			},
-			GridMinorStyle: chart.Style{
-				StrokeColor: gridColor,
-				StrokeWidth: gridWidth,
-			},
-			Range: &chart.ContinuousRange{
-				Min: 0.0,
-				Max: 55.0,
-			},
-			ValueFormatter: func(v interface{}) string {
-				if i, ok := v.(float64); ok {
-					return fmt.Sprintf("%.0f", i)
-				}
-				return ""
-			},
-		},
-		Series: []chart.Series{
-			chart.TimeSeries{
-				Style: chart.Style{
-					StrokeColor: drawing.ColorFromHex("4692e8"),
-					StrokeWidth: 2,
-
-					DotColor: drawing.ColorFromHex("4692e8"),
-					DotWidth: 3,
-				},
-				XValues: []time.Time{
-					time.Now().AddDate(0, 0, -9),
-					time.Now().AddDate(0, 0, -8),
-					time.Now().AddDate(0, 0, -7),
-					time.Now().AddDate(0, 0, -6),
-					time.Now().AddDate(0, 0, -5),
-					time.Now().AddDate(0, 0, -4),
-					time.Now(),
-				},
-				YValues: []float64{50.0, 40.0, 47.0, 45.0, 22.0, 35.0, 33.0},
-			},
-			chart.TimeSeries{
-				YValues: []float64{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0},
-			},
-		},
+	days := []string{}
+	for _, day := range []int{-9, -8, -7, -6, -5, -4, 0} {
+		days = append(days, time.Now().AddDate(0, 0, day).Format("02.01"))
 	}
 
-	var imageBuf bytes.Buffer
-	writer := bufio.NewWriter(&imageBuf)
-
-	graph.Render(chart.PNG, writer)
-
 	if err := doc.SetP(&zdocx.Paragraph{
 		Style: zdocx.PStyle{
 			HorisontalAlign: "center",
 		},
 		Texts: []*zdocx.Text{
 			{
-				Image: &zdocx.Image{
-					FileName: "temp_image.png",
-					Bytes:    imageBuf.Bytes(),
-					Width:    165,
+				Chart: &zdocx.Chart{
+					Type:       zdocx.ChartTypeLine,
+					Categories: days,
+					Series: []*zdocx.ChartSeries{
+						{
+							Name:   "value",
+							Values: []float64{50.0, 40.0, 47.0, 45.0, 22.0, 35.0, 33.0},
+							Color:  "4692E8",
+						},
+					},
+					NoLegend:     true,
+					WidthPercent: 100,
+					Height:       60,
+					SizeUnit:     zdocx.SizeUnitMM,
 				},
 			},
 		},
@@ -0,0 +1,227 @@
+package zdocx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+const (
+	CaptionLabelFigure    = "Figure"
+	CaptionLabelTable     = "Table"
+	CaptionPositionAbove  = "above"
+	CaptionPositionBelow  = "below"
+	CaptionSeparatorDash  = " — "
+	captionStyleClass     = "Caption"
+	captionListStyleClass = "TableofFigures"
+	captionBookmarkPrefix = "_TocCaption"
+)
+
+type Caption struct {
+	Label     string
+	Text      string
+	Separator string
+	Position  string
+}
+
+type captionEntry struct {
+	label    string
+	text     string
+	bookmark string
+}
+
+func (c *Caption) getLabel(defaultLabel string) string {
+	if c.Label == "" {
+		return defaultLabel
+	}
+
+	return c.Label
+}
+
+func (c *Caption) getSeparator() string {
+	if c.Separator == "" {
+		return CaptionSeparatorDash
+	}
+
+	return c.Separator
+}
+
+func (c *Caption) isAbove(defaultPosition string) bool {
+	if c.Position == "" {
+		return defaultPosition == CaptionPositionAbove
+	}
+
+	return c.Position == CaptionPositionAbove
+}
+
+type writeCaptionArgs struct {
+	caption         *Caption
+	defaultLabel    string
+	defaultPosition string
+	above           bool
+}
+
+func (d *Document) writeCaption(w xmlWriter, args writeCaptionArgs) {
+	if args.caption == nil || args.caption.isAbove(args.defaultPosition) != args.above {
+		return
+	}
+
+	if d.captionNumbers == nil {
+		d.captionNumbers = map[string]int{}
+	}
+
+	label := args.caption.getLabel(args.defaultLabel)
+	d.captionNumbers[label]++
+	number := strconv.Itoa(d.captionNumbers[label])
+
+	d.bookmarksCount++
+	bookmarkID := strconv.Itoa(d.bookmarksCount)
+	bookmark := captionBookmarkPrefix + bookmarkID
+
+	text := label + " " + number
+	if args.caption.Text != "" {
+		text += args.caption.getSeparator() + args.caption.Text
+	}
+
+	d.captions = append(d.captions, &captionEntry{
+		label:    label,
+		text:     text,
+		bookmark: bookmark,
+	})
+
+	w.WriteString(`<w:p>`)
+	w.WriteString(`<w:pPr>`)
+	w.WriteString(`<w:pStyle w:val="` + captionStyleClass + `"/>`)
+
+	if args.above {
+		w.WriteString(`<w:keepNext/>`)
+	}
+
+	w.WriteString(`</w:pPr>`)
+	w.WriteString(`<w:bookmarkStart w:id="` + bookmarkID + `" w:name="` + bookmark + `"/>`)
+	w.WriteString(textRun(label + " "))
+
+	field := Field{
+		Type:     FieldTypeSequence,
+		Argument: label,
+		Format:   FieldFormatArabic,
+		Result:   number,
+	}
+	field.write(w, d, "")
+
+	if args.caption.Text != "" {
+		w.WriteString(textRun(args.caption.getSeparator() + args.caption.Text))
+	}
+
+	w.WriteString(`<w:bookmarkEnd w:id="` + bookmarkID + `"/>`)
+	w.WriteString(`</w:p>`)
+}
+
+func textRun(text string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<w:r><w:t xml:space="preserve">`)
+	xml.EscapeText(&buf, []byte(text))
+	buf.WriteString(`</w:t></w:r>`)
+
+	return buf.String()
+}
+
+func (d *Document) SetListOfFigures() {
+	d.setListOfCaptions(CaptionLabelFigure)
+}
+
+func (d *Document) SetListOfTables() {
+	d.setListOfCaptions(CaptionLabelTable)
+}
+
+func (d *Document) SetListOfCaptions(label string) {
+	d.setListOfCaptions(label)
+}
+
+// Captions set after the list are not known yet, so in a regular document
+// the list is rendered at save time in place of a placeholder. A streaming
+// document can only list the captions written so far, the field is marked
+// dirty either way so Word refreshes it with page numbers.
+func (d *Document) setListOfCaptions(label string) {
+	if d.isStreaming() {
+		d.body().WriteString(d.listOfCaptions(label))
+		return
+	}
+
+	d.captionLists = append(d.captionLists, label)
+	d.body().WriteString(captionListPlaceholder(len(d.captionLists) - 1))
+}
+
+func captionListPlaceholder(index int) string {
+	return `<!--zdocx-list-of-captions-` + strconv.Itoa(index) + `-->`
+}
+
+func (d *Document) resolveCaptionLists(content []byte) []byte {
+	for index, label := range d.captionLists {
+		content = bytes.Replace(content, []byte(captionListPlaceholder(index)), []byte(d.listOfCaptions(label)), 1)
+	}
+
+	return content
+}
+
+func (d *Document) listOfCaptions(label string) string {
+	var entries []*captionEntry
+
+	for _, entry := range d.captions {
+		if entry.label == label {
+			entries = append(entries, entry)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fieldStart := `<w:r><w:fldChar w:fldCharType="begin" w:dirty="true"/></w:r>` +
+		`<w:r><w:instrText xml:space="preserve"> TOC \h \z \c "` + escapeAttr(label) + `" </w:instrText></w:r>` +
+		`<w:r><w:fldChar w:fldCharType="separate"/></w:r>`
+	fieldEnd := `<w:r><w:fldChar w:fldCharType="end"/></w:r>`
+
+	if len(entries) == 0 {
+		buf.WriteString(`<w:p>`)
+		buf.WriteString(captionListProperties(d.GetInnerWidth()))
+		buf.WriteString(fieldStart)
+		buf.WriteString(fieldEnd)
+		buf.WriteString(`</w:p>`)
+
+		return buf.String()
+	}
+
+	for index, entry := range entries {
+		buf.WriteString(`<w:p>`)
+		buf.WriteString(captionListProperties(d.GetInnerWidth()))
+
+		if index == 0 {
+			buf.WriteString(fieldStart)
+		}
+
+		buf.WriteString(`<w:hyperlink w:anchor="` + entry.bookmark + `" w:history="1">`)
+		buf.WriteString(textRun(entry.text))
+		buf.WriteString(`<w:r><w:tab/></w:r>`)
+
+		pageReference := Field{
+			Type:     FieldTypePageReference,
+			Argument: entry.bookmark,
+			Switches: []string{`\h`},
+		}
+		pageReference.write(&buf, d, "")
+
+		buf.WriteString(`</w:hyperlink>`)
+
+		if index == len(entries)-1 {
+			buf.WriteString(fieldEnd)
+		}
+
+		buf.WriteString(`</w:p>`)
+	}
+
+	return buf.String()
+}
+
+func captionListProperties(width int) string {
+	return `<w:pPr><w:pStyle w:val="` + captionListStyleClass + `"/><w:tabs><w:tab w:val="right" w:leader="dot" w:pos="` + strconv.Itoa(width) + `"/></w:tabs></w:pPr>`
+}
@@ -0,0 +1,67 @@
+package zdocx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaptions(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	doc.SetListOfFigures()
+
+	for _, text := range []string{"Sales", "Costs"} {
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: &Image{Bytes: testPNG(t, 2, 2), Caption: &Caption{Text: text}}}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table := testTable(1)
+	table.Caption = &Caption{Text: "Totals", Separator: ": "}
+
+	if err := doc.SetTable(table); err != nil {
+		t.Fatal(err)
+	}
+
+	doc.SetListOfTables()
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := string(zipEntries(t, buf.Bytes())["word/document.xml"])
+
+	for _, expected := range []string{
+		` SEQ Figure \* Arabic `,
+		`<w:t xml:space="preserve">2</w:t>`,
+		`<w:t xml:space="preserve"> — Costs</w:t>`,
+		`<w:t xml:space="preserve">: Totals</w:t>`,
+		` TOC \h \z \c "Figure" `,
+		` TOC \h \z \c "Table" `,
+		`<w:t xml:space="preserve">Figure 1 — Sales</w:t>`,
+		`<w:t xml:space="preserve">Figure 2 — Costs</w:t>`,
+		`<w:t xml:space="preserve">Table 1: Totals</w:t>`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("no %s in document.xml", expected)
+		}
+	}
+
+	if strings.Contains(document, "zdocx-list-of-captions") {
+		t.Error("list placeholder left in document.xml")
+	}
+
+	// the list of figures comes first although it was set before the figures
+	if strings.Index(document, "Figure 2 — Costs") > strings.Index(document, ` SEQ Figure`) {
+		t.Error("list of figures is not in place of its placeholder")
+	}
+
+	// table captions go above the table, figure captions below the image
+	if strings.Index(document, ": Totals") > strings.Index(document, "<w:tbl>") {
+		t.Error("table caption is not above the table")
+	}
+
+	if strings.Index(document, `<w:t xml:space="preserve"> — Sales</w:t>`) < strings.Index(document, "<w:drawing>") {
+		t.Error("figure caption is not below the image")
+	}
+}
@@ -0,0 +1,572 @@
+package zdocx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cfbSectorSize       = 512
+	cfbMiniSectorSize   = 64
+	cfbMiniStreamCutoff = 4096
+	cfbHeaderDIFATSize  = 109
+	cfbFreeSector       = 0xFFFFFFFF
+	cfbEndOfChain       = 0xFFFFFFFE
+	cfbFATSector        = 0xFFFFFFFD
+	cfbDIFATSector      = 0xFFFFFFFC
+	cfbNoStream         = 0xFFFFFFFF
+)
+
+// compoundEntry is a storage or a stream of an OLE compound file (MS-CFB),
+// the container of encrypted packages.
+type compoundEntry struct {
+	name      string
+	data      []byte
+	isStorage bool
+	children  []*compoundEntry
+	id        uint32
+	start     uint32
+	left      uint32
+	right     uint32
+	child     uint32
+}
+
+var compoundFileSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+func isCompoundFile(data []byte) bool {
+	return bytes.HasPrefix(data, compoundFileSignature)
+}
+
+// add puts a stream into the storage, path separates storages by "/".
+func (e *compoundEntry) add(path string, data []byte) {
+	parts := strings.SplitN(path, "/", 2)
+
+	if len(parts) == 1 {
+		e.children = append(e.children, &compoundEntry{name: path, data: data})
+		return
+	}
+
+	for _, child := range e.children {
+		if child.isStorage && child.name == parts[0] {
+			child.add(parts[1], data)
+			return
+		}
+	}
+
+	storage := &compoundEntry{name: parts[0], isStorage: true}
+	storage.add(parts[1], data)
+	e.children = append(e.children, storage)
+}
+
+// compoundNameLess orders entries the way the directory tree has to be
+// sorted, shorter names first, then case insensitive.
+func compoundNameLess(a string, b string) bool {
+	ua := utf16.Encode([]rune(strings.ToUpper(a)))
+	ub := utf16.Encode([]rune(strings.ToUpper(b)))
+
+	if len(ua) != len(ub) {
+		return len(ua) < len(ub)
+	}
+
+	for index := range ua {
+		if ua[index] != ub[index] {
+			return ua[index] < ub[index]
+		}
+	}
+
+	return false
+}
+
+func (e *compoundEntry) entries() []*compoundEntry {
+	entries := []*compoundEntry{e}
+
+	sort.Slice(e.children, func(i int, j int) bool {
+		return compoundNameLess(e.children[i].name, e.children[j].name)
+	})
+
+	for _, child := range e.children {
+		entries = append(entries, child.entries()...)
+	}
+
+	return entries
+}
+
+// compoundTree links sorted siblings into a balanced binary tree and
+// returns the id of its root.
+func compoundTree(siblings []*compoundEntry) uint32 {
+	if len(siblings) == 0 {
+		return cfbNoStream
+	}
+
+	middle := len(siblings) / 2
+	root := siblings[middle]
+	root.left = compoundTree(siblings[:middle])
+	root.right = compoundTree(siblings[middle+1:])
+
+	return root.id
+}
+
+type compoundSectors struct {
+	body bytes.Buffer
+	fat  []uint32
+}
+
+func (s *compoundSectors) allocate(data []byte) uint32 {
+	if len(data) == 0 {
+		return cfbEndOfChain
+	}
+
+	start := uint32(len(s.fat))
+	count := (len(data) + cfbSectorSize - 1) / cfbSectorSize
+
+	for index := 0; index < count; index++ {
+		s.fat = append(s.fat, start+uint32(index)+1)
+	}
+
+	s.fat[len(s.fat)-1] = cfbEndOfChain
+
+	s.body.Write(data)
+	s.body.Write(make([]byte, count*cfbSectorSize-len(data)))
+
+	return start
+}
+
+func uint32Bytes(values []uint32, count int, filler uint32) []byte {
+	b := make([]byte, count*4)
+
+	for index := 0; index < count; index++ {
+		value := filler
+		if index < len(values) {
+			value = values[index]
+		}
+
+		binary.LittleEndian.PutUint32(b[index*4:], value)
+	}
+
+	return b
+}
+
+// writeCompoundFile writes a version 3 compound file with 512 byte sectors,
+// streams under the cutoff go to the mini stream of the root entry.
+func writeCompoundFile(root *compoundEntry) ([]byte, error) {
+	root.isStorage = true
+
+	entries := root.entries()
+	if len(entries) > 0xFFFF {
+		return nil, errors.New("too many compound file entries")
+	}
+
+	for index, e := range entries {
+		e.id = uint32(index)
+
+		if len([]rune(e.name)) > 31 {
+			return nil, errors.New("compound file entry name is longer than 31 characters: " + e.name)
+		}
+	}
+
+	for _, e := range entries {
+		e.left, e.right, e.child = cfbNoStream, cfbNoStream, cfbNoStream
+	}
+
+	for _, e := range entries {
+		if e.isStorage {
+			e.child = compoundTree(e.children)
+		}
+	}
+
+	var (
+		sectors    compoundSectors
+		miniStream bytes.Buffer
+		miniFAT    []uint32
+	)
+
+	for _, e := range entries[1:] {
+		if e.isStorage {
+			continue
+		}
+
+		switch {
+		case len(e.data) == 0:
+			e.start = cfbEndOfChain
+		case len(e.data) < cfbMiniStreamCutoff:
+			e.start = uint32(len(miniFAT))
+			count := (len(e.data) + cfbMiniSectorSize - 1) / cfbMiniSectorSize
+
+			for index := 0; index < count; index++ {
+				miniFAT = append(miniFAT, e.start+uint32(index)+1)
+			}
+
+			miniFAT[len(miniFAT)-1] = cfbEndOfChain
+
+			miniStream.Write(e.data)
+			miniStream.Write(make([]byte, count*cfbMiniSectorSize-len(e.data)))
+		default:
+			e.start = sectors.allocate(e.data)
+		}
+	}
+
+	root.data = miniStream.Bytes()
+	root.start = sectors.allocate(root.data)
+
+	perSector := cfbSectorSize / 4
+	miniFATSectors := (len(miniFAT) + perSector - 1) / perSector
+	firstMiniFAT := sectors.allocate(uint32Bytes(miniFAT, miniFATSectors*perSector, cfbFreeSector))
+
+	var directory bytes.Buffer
+
+	for _, e := range entries {
+		directory.Write(e.directoryEntry())
+	}
+
+	for directory.Len()%cfbSectorSize != 0 {
+		directory.Write(unusedDirectoryEntry())
+	}
+
+	firstDirectory := sectors.allocate(directory.Bytes())
+
+	// FAT and DIFAT sectors are listed in the FAT as well, so their counts
+	// depend on each other.
+	dataSectors := len(sectors.fat)
+	fatSectors, difatSectors := 0, 0
+
+	for {
+		total := dataSectors + fatSectors + difatSectors
+		neededFAT := (total + perSector - 1) / perSector
+		neededDIFAT := 0
+
+		if neededFAT > cfbHeaderDIFATSize {
+			neededDIFAT = (neededFAT - cfbHeaderDIFATSize + perSector - 2) / (perSector - 1)
+		}
+
+		if neededFAT == fatSectors && neededDIFAT == difatSectors {
+			break
+		}
+
+		fatSectors, difatSectors = neededFAT, neededDIFAT
+	}
+
+	fatLocations := make([]uint32, fatSectors)
+
+	for index := range fatLocations {
+		fatLocations[index] = uint32(dataSectors + index)
+		sectors.fat = append(sectors.fat, cfbFATSector)
+	}
+
+	for index := 0; index < difatSectors; index++ {
+		sectors.fat = append(sectors.fat, cfbDIFATSector)
+	}
+
+	var buf bytes.Buffer
+
+	header := make([]byte, cfbSectorSize)
+	copy(header, compoundFileSignature)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 0x0003)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], uint32(fatSectors))
+	binary.LittleEndian.PutUint32(header[48:], firstDirectory)
+	binary.LittleEndian.PutUint32(header[56:], cfbMiniStreamCutoff)
+	binary.LittleEndian.PutUint32(header[60:], firstMiniFAT)
+	binary.LittleEndian.PutUint32(header[64:], uint32(miniFATSectors))
+	binary.LittleEndian.PutUint32(header[68:], cfbEndOfChain)
+	binary.LittleEndian.PutUint32(header[72:], uint32(difatSectors))
+
+	if difatSectors > 0 {
+		binary.LittleEndian.PutUint32(header[68:], uint32(dataSectors+fatSectors))
+	}
+
+	headerDIFAT := fatLocations
+	if len(headerDIFAT) > cfbHeaderDIFATSize {
+		headerDIFAT = headerDIFAT[:cfbHeaderDIFATSize]
+	}
+
+	copy(header[76:], uint32Bytes(headerDIFAT, cfbHeaderDIFATSize, cfbFreeSector))
+
+	buf.Write(header)
+	buf.Write(sectors.body.Bytes())
+	buf.Write(uint32Bytes(sectors.fat, fatSectors*perSector, cfbFreeSector))
+
+	rest := fatLocations[len(headerDIFAT):]
+
+	for index := 0; index < difatSectors; index++ {
+		count := perSector - 1
+		if count > len(rest) {
+			count = len(rest)
+		}
+
+		next := uint32(cfbEndOfChain)
+		if index < difatSectors-1 {
+			next = uint32(dataSectors + fatSectors + index + 1)
+		}
+
+		buf.Write(uint32Bytes(rest[:count], perSector-1, cfbFreeSector))
+		buf.Write(uint32Bytes([]uint32{next}, 1, 0))
+
+		rest = rest[count:]
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (e *compoundEntry) directoryEntry() []byte {
+	b := make([]byte, 128)
+
+	name := utf16.Encode([]rune(e.name))
+	for index, unit := range name {
+		binary.LittleEndian.PutUint16(b[index*2:], unit)
+	}
+
+	binary.LittleEndian.PutUint16(b[64:], uint16((len(name)+1)*2))
+
+	switch {
+	case e.id == 0:
+		b[66] = 5
+	case e.isStorage:
+		b[66] = 1
+	default:
+		b[66] = 2
+	}
+
+	b[67] = 1
+	binary.LittleEndian.PutUint32(b[68:], e.left)
+	binary.LittleEndian.PutUint32(b[72:], e.right)
+	binary.LittleEndian.PutUint32(b[76:], e.child)
+
+	if e.id == 0 || !e.isStorage {
+		binary.LittleEndian.PutUint32(b[116:], e.start)
+		binary.LittleEndian.PutUint64(b[120:], uint64(len(e.data)))
+	}
+
+	return b
+}
+
+func unusedDirectoryEntry() []byte {
+	b := make([]byte, 128)
+	binary.LittleEndian.PutUint32(b[68:], cfbNoStream)
+	binary.LittleEndian.PutUint32(b[72:], cfbNoStream)
+	binary.LittleEndian.PutUint32(b[76:], cfbNoStream)
+
+	return b
+}
+
+type compoundReader struct {
+	data       []byte
+	sectorSize int
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	cutoff     uint64
+}
+
+// readCompoundFile returns the streams of a compound file by their path,
+// storages are separated by "/".
+func readCompoundFile(data []byte) (map[string][]byte, error) {
+	if len(data) < 512 || !isCompoundFile(data) {
+		return nil, errors.New("not a compound file")
+	}
+
+	shift := binary.LittleEndian.Uint16(data[30:])
+	if shift != 9 && shift != 12 {
+		return nil, errors.New("unsupported compound file sector size")
+	}
+
+	r := compoundReader{
+		data:       data,
+		sectorSize: 1 << shift,
+		cutoff:     uint64(binary.LittleEndian.Uint32(data[56:])),
+	}
+
+	var fatLocations []uint32
+
+	for index := 0; index < cfbHeaderDIFATSize; index++ {
+		fatLocations = append(fatLocations, binary.LittleEndian.Uint32(data[76+index*4:]))
+	}
+
+	next := binary.LittleEndian.Uint32(data[68:])
+
+	for count := 0; next < cfbDIFATSector; count++ {
+		sector, err := r.sector(next)
+		if err != nil {
+			return nil, errors.Wrap(err, "r.sector")
+		}
+
+		if count > len(data)/r.sectorSize {
+			return nil, errors.New("DIFAT loop")
+		}
+
+		perSector := r.sectorSize/4 - 1
+
+		for index := 0; index < perSector; index++ {
+			fatLocations = append(fatLocations, binary.LittleEndian.Uint32(sector[index*4:]))
+		}
+
+		next = binary.LittleEndian.Uint32(sector[perSector*4:])
+	}
+
+	for _, location := range fatLocations {
+		if location >= cfbDIFATSector {
+			continue
+		}
+
+		sector, err := r.sector(location)
+		if err != nil {
+			return nil, errors.Wrap(err, "r.sector")
+		}
+
+		r.fat = append(r.fat, readUint32s(sector)...)
+	}
+
+	directory, err := r.chain(binary.LittleEndian.Uint32(data[48:]), r.fat, r.sector)
+	if err != nil {
+		return nil, errors.Wrap(err, "r.chain")
+	}
+
+	if len(directory) < 128 {
+		return nil, errors.New("no root entry")
+	}
+
+	if firstMiniFAT := binary.LittleEndian.Uint32(data[60:]); firstMiniFAT < cfbDIFATSector {
+		miniFAT, err := r.chain(firstMiniFAT, r.fat, r.sector)
+		if err != nil {
+			return nil, errors.Wrap(err, "r.chain")
+		}
+
+		r.miniFAT = readUint32s(miniFAT)
+	}
+
+	root := directory[:128]
+
+	if r.miniStream, err = r.chain(binary.LittleEndian.Uint32(root[116:]), r.fat, r.sector); err != nil {
+		return nil, errors.Wrap(err, "r.chain")
+	}
+
+	streams := map[string][]byte{}
+	visited := map[uint32]bool{}
+
+	if err := r.readTree(directory, binary.LittleEndian.Uint32(root[76:]), "", streams, visited); err != nil {
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+func (r *compoundReader) readTree(directory []byte, id uint32, path string, streams map[string][]byte, visited map[uint32]bool) error {
+	if id == cfbNoStream {
+		return nil
+	}
+
+	if visited[id] || int(id+1)*128 > len(directory) {
+		return errors.New("broken compound file directory")
+	}
+
+	visited[id] = true
+	entry := directory[id*128 : (id+1)*128]
+
+	for _, sibling := range []uint32{binary.LittleEndian.Uint32(entry[68:]), binary.LittleEndian.Uint32(entry[72:])} {
+		if err := r.readTree(directory, sibling, path, streams, visited); err != nil {
+			return err
+		}
+	}
+
+	nameLength := int(binary.LittleEndian.Uint16(entry[64:]))/2 - 1
+	if nameLength < 0 || nameLength > 31 {
+		return errors.New("broken compound file entry name")
+	}
+
+	units := make([]uint16, nameLength)
+	for index := range units {
+		units[index] = binary.LittleEndian.Uint16(entry[index*2:])
+	}
+
+	name := path + string(utf16.Decode(units))
+
+	switch entry[66] {
+	case 1:
+		return r.readTree(directory, binary.LittleEndian.Uint32(entry[76:]), name+"/", streams, visited)
+	case 2:
+		size := binary.LittleEndian.Uint64(entry[120:])
+		if r.sectorSize == 512 {
+			size &= 0xFFFFFFFF
+		}
+
+		start := binary.LittleEndian.Uint32(entry[116:])
+
+		var (
+			data []byte
+			err  error
+		)
+
+		if size < r.cutoff {
+			data, err = r.chain(start, r.miniFAT, r.miniSector)
+		} else {
+			data, err = r.chain(start, r.fat, r.sector)
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "r.chain")
+		}
+
+		if uint64(len(data)) < size {
+			return errors.New("short compound file stream " + name)
+		}
+
+		streams[name] = data[:size]
+	}
+
+	return nil
+}
+
+func (r *compoundReader) sector(id uint32) ([]byte, error) {
+	offset := (int(id) + 1) * r.sectorSize
+	if offset+r.sectorSize > len(r.data) {
+		return nil, errors.New("compound file sector out of range")
+	}
+
+	return r.data[offset : offset+r.sectorSize], nil
+}
+
+func (r *compoundReader) miniSector(id uint32) ([]byte, error) {
+	offset := int(id) * cfbMiniSectorSize
+	if offset+cfbMiniSectorSize > len(r.miniStream) {
+		return nil, errors.New("compound file mini sector out of range")
+	}
+
+	return r.miniStream[offset : offset+cfbMiniSectorSize], nil
+}
+
+func (r *compoundReader) chain(start uint32, fat []uint32, sector func(id uint32) ([]byte, error)) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for id, count := start, 0; id < cfbDIFATSector; count++ {
+		if count > len(fat) || int(id) >= len(fat) {
+			return nil, errors.New("broken compound file chain")
+		}
+
+		b, err := sector(id)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(b)
+		id = fat[id]
+	}
+
+	return buf.Bytes(), nil
+}
+
+func readUint32s(b []byte) []uint32 {
+	values := make([]uint32, len(b)/4)
+
+	for index := range values {
+		values[index] = binary.LittleEndian.Uint32(b[index*4:])
+	}
+
+	return values
+}
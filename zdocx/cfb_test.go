@@ -0,0 +1,58 @@
+package zdocx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompoundFile(t *testing.T) {
+	streams := map[string][]byte{
+		"Empty":              {},
+		"Small":              randomData(100),
+		"Cutoff":             randomData(cfbMiniStreamCutoff - 1),
+		"Regular":            randomData(cfbMiniStreamCutoff),
+		"Storage/Nested":     randomData(700),
+		"Storage/Deeper/Big": randomData(70000),
+	}
+
+	data := compoundFile(t, streams)
+
+	if !isCompoundFile(data) || len(data)%cfbSectorSize != 0 {
+		t.Fatal("not a compound file of whole sectors")
+	}
+
+	read, err := readCompoundFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range streams {
+		if !bytes.Equal(read[name], expected) {
+			t.Errorf("%s: %d bytes read, %d written", name, len(read[name]), len(expected))
+		}
+	}
+
+	if len(read) != len(streams) {
+		t.Errorf("%d streams read, %d written", len(read), len(streams))
+	}
+
+	if _, err := readCompoundFile(data[:len(data)-cfbSectorSize]); err == nil {
+		t.Error("truncated compound file read")
+	}
+
+	if _, err := readCompoundFile([]byte("PK\x03\x04")); err == nil {
+		t.Error("zip read as a compound file")
+	}
+}
+
+func TestCompoundNameOrder(t *testing.T) {
+	for _, names := range [][2]string{
+		{"B", "AA"},
+		{"a", "B"},
+		{"EncryptionInfo", "EncryptedPackage"},
+	} {
+		if !compoundNameLess(names[0], names[1]) || compoundNameLess(names[1], names[0]) {
+			t.Errorf("%q is not before %q", names[0], names[1])
+		}
+	}
+}
@@ -0,0 +1,708 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	chartsID            = "fileChartID"
+	chartCategoryAxisID = "100000001"
+	chartValueAxisID    = "100000002"
+	chartAxisIDs        = `<c:axId val="` + chartCategoryAxisID + `"/><c:axId val="` + chartValueAxisID + `"/>`
+	ChartTypeBar        = "bar"
+	ChartTypeLine       = "line"
+	ChartTypeArea       = "area"
+	ChartTypePie        = "pie"
+	ChartTypeScatter    = "scatter"
+	ChartLegendRight    = "r"
+	ChartLegendLeft     = "l"
+	ChartLegendTop      = "t"
+	ChartLegendBottom   = "b"
+)
+
+type Chart struct {
+	Type         string
+	Title        string
+	Categories   []string
+	Series       []*ChartSeries
+	XAxisTitle   string
+	YAxisTitle   string
+	Legend       string
+	NoLegend     bool
+	IsHorisontal bool
+	Name         string
+	Width        int64
+	Height       int64
+	WidthPercent float64
+	SizeUnit     string
+	part         []byte
+	workbookPart []byte
+}
+
+type ChartSeries struct {
+	Name        string
+	Values      []float64
+	XValues     []float64
+	Color       string
+	PointColors []string
+}
+
+func (c *Chart) Error() error {
+	if c.part != nil {
+		return nil
+	}
+
+	switch c.Type {
+	case ChartTypeBar, ChartTypeLine, ChartTypeArea, ChartTypePie, ChartTypeScatter:
+	default:
+		return errors.New("unsupported chart type " + c.Type)
+	}
+
+	if len(c.Series) == 0 {
+		return errors.New("no chart.Series")
+	}
+
+	for _, s := range c.Series {
+		if c.Type == ChartTypeScatter && len(s.XValues) != len(s.Values) {
+			return errors.New("scatter series " + s.Name + " needs one XValue per value")
+		}
+	}
+
+	return nil
+}
+
+func (c *Chart) write(w xmlWriter, d *Document) error {
+	if err := c.Error(); err != nil {
+		return err
+	}
+
+	if d.currentPart != nil {
+		return errors.New("charts are not supported in headers, footers and notes")
+	}
+
+	number := d.chartNumber(c)
+
+	d.drawingsCount++
+
+	name := c.Name
+	if name == "" {
+		name = "Chart " + strconv.Itoa(number)
+	}
+
+	width, height := c.sizes(d)
+
+	w.WriteString(`<w:r>`)
+	w.WriteString(`<w:drawing>`)
+	w.WriteString(`<wp:inline distT="0" distB="0" distL="0" distR="0">`)
+	w.WriteString(`<wp:extent cx="` + strconv.FormatInt(width, 10) + `" cy="` + strconv.FormatInt(height, 10) + `"/>`)
+	w.WriteString(`<wp:effectExtent l="0" t="0" r="0" b="0"/>`)
+	w.WriteString(`<wp:docPr id="` + strconv.Itoa(d.drawingsCount) + `" name="` + escapeAttr(name) + `" descr="` + escapeAttr(c.Title) + `"/>`)
+	w.WriteString(`<wp:cNvGraphicFramePr/>`)
+	w.WriteString(`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+	w.WriteString(`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/chart">`)
+	w.WriteString(`<c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" r:id="` + chartRelsID(number) + `"/>`)
+	w.WriteString(`</a:graphicData>`)
+	w.WriteString(`</a:graphic>`)
+	w.WriteString(`</wp:inline>`)
+	w.WriteString(`</w:drawing>`)
+	w.WriteString(`</w:r>`)
+
+	return nil
+}
+
+func (c *Chart) sizes(d *Document) (int64, int64) {
+	width := sizeToEMU(c.Width, c.SizeUnit, defaultDPI)
+	height := sizeToEMU(c.Height, c.SizeUnit, defaultDPI)
+
+	if c.WidthPercent > 0 {
+		width = int64(float64(dxaToEMU(int64(d.containerWidth()))) * c.WidthPercent / 100)
+	}
+
+	if width == 0 {
+		width = dxaToEMU(int64(d.containerWidth()))
+	}
+
+	if height == 0 {
+		height = width * 3 / 5
+	}
+
+	return width, height
+}
+
+// chartNumber returns the number of the chart part in d, registering c on
+// its first write so that writing the same chart again reuses the part.
+func (d *Document) chartNumber(c *Chart) int {
+	for i, chart := range d.charts {
+		if chart == c {
+			return i + 1
+		}
+	}
+
+	d.charts = append(d.charts, c)
+
+	return len(d.charts)
+}
+
+func chartRelsID(number int) string {
+	return chartsID + strconv.Itoa(number)
+}
+
+func chartFileName(number int) string {
+	return "chart" + strconv.Itoa(number) + ".xml"
+}
+
+func chartWorkbookName(number int) string {
+	return "Microsoft_Excel_Worksheet" + strconv.Itoa(number) + ".xlsx"
+}
+
+func (c *Chart) rowsCount() int {
+	if c.Type != ChartTypeScatter {
+		return len(c.Categories)
+	}
+
+	var count int
+
+	for _, s := range c.Series {
+		if len(s.Values) > count {
+			count = len(s.Values)
+		}
+	}
+
+	return count
+}
+
+func (c *Chart) xml() string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	buf.WriteString(`<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	buf.WriteString(`<c:roundedCorners val="0"/>`)
+	buf.WriteString(`<c:chart>`)
+
+	if c.Title != "" {
+		buf.WriteString(chartTitle(c.Title))
+		buf.WriteString(`<c:autoTitleDeleted val="0"/>`)
+	} else {
+		buf.WriteString(`<c:autoTitleDeleted val="1"/>`)
+	}
+
+	buf.WriteString(`<c:plotArea>`)
+	buf.WriteString(`<c:layout/>`)
+	buf.WriteString(c.plot())
+	buf.WriteString(c.axes())
+	buf.WriteString(`</c:plotArea>`)
+
+	if !c.NoLegend {
+		buf.WriteString(`<c:legend>`)
+		buf.WriteString(`<c:legendPos val="` + c.getLegend() + `"/>`)
+		buf.WriteString(`<c:overlay val="0"/>`)
+		buf.WriteString(`</c:legend>`)
+	}
+
+	buf.WriteString(`<c:plotVisOnly val="1"/>`)
+	buf.WriteString(`<c:dispBlanksAs val="gap"/>`)
+	buf.WriteString(`</c:chart>`)
+	buf.WriteString(`<c:externalData r:id="rId1">`)
+	buf.WriteString(`<c:autoUpdate val="0"/>`)
+	buf.WriteString(`</c:externalData>`)
+	buf.WriteString(`</c:chartSpace>`)
+
+	return buf.String()
+}
+
+func (c *Chart) getLegend() string {
+	switch c.Legend {
+	case ChartLegendTop, ChartLegendBottom, ChartLegendLeft:
+		return c.Legend
+	default:
+		return ChartLegendRight
+	}
+}
+
+func (c *Chart) plot() string {
+	var buf bytes.Buffer
+
+	switch c.Type {
+	case ChartTypeBar:
+		barDir := "col"
+		if c.IsHorisontal {
+			barDir = "bar"
+		}
+
+		buf.WriteString(`<c:barChart>`)
+		buf.WriteString(`<c:barDir val="` + barDir + `"/>`)
+		buf.WriteString(`<c:grouping val="clustered"/>`)
+		buf.WriteString(`<c:varyColors val="0"/>`)
+		buf.WriteString(c.series())
+		buf.WriteString(`<c:gapWidth val="150"/>`)
+		buf.WriteString(chartAxisIDs)
+		buf.WriteString(`</c:barChart>`)
+	case ChartTypeLine:
+		buf.WriteString(`<c:lineChart>`)
+		buf.WriteString(`<c:grouping val="standard"/>`)
+		buf.WriteString(`<c:varyColors val="0"/>`)
+		buf.WriteString(c.series())
+		buf.WriteString(`<c:marker val="1"/>`)
+		buf.WriteString(chartAxisIDs)
+		buf.WriteString(`</c:lineChart>`)
+	case ChartTypeArea:
+		buf.WriteString(`<c:areaChart>`)
+		buf.WriteString(`<c:grouping val="standard"/>`)
+		buf.WriteString(`<c:varyColors val="0"/>`)
+		buf.WriteString(c.series())
+		buf.WriteString(chartAxisIDs)
+		buf.WriteString(`</c:areaChart>`)
+	case ChartTypePie:
+		buf.WriteString(`<c:pieChart>`)
+		buf.WriteString(`<c:varyColors val="1"/>`)
+		buf.WriteString(c.series())
+		buf.WriteString(`<c:firstSliceAng val="0"/>`)
+		buf.WriteString(`</c:pieChart>`)
+	case ChartTypeScatter:
+		buf.WriteString(`<c:scatterChart>`)
+		buf.WriteString(`<c:scatterStyle val="lineMarker"/>`)
+		buf.WriteString(`<c:varyColors val="0"/>`)
+		buf.WriteString(c.series())
+		buf.WriteString(chartAxisIDs)
+		buf.WriteString(`</c:scatterChart>`)
+	}
+
+	return buf.String()
+}
+
+func (c *Chart) series() string {
+	var buf bytes.Buffer
+
+	rows := c.rowsCount()
+	lastRow := strconv.Itoa(rows + 1)
+
+	for index, s := range c.Series {
+		xColumn, column := c.seriesColumns(index)
+
+		buf.WriteString(`<c:ser>`)
+		buf.WriteString(`<c:idx val="` + strconv.Itoa(index) + `"/>`)
+		buf.WriteString(`<c:order val="` + strconv.Itoa(index) + `"/>`)
+		buf.WriteString(`<c:tx>`)
+		buf.WriteString(`<c:strRef>`)
+		buf.WriteString(`<c:f>Sheet1!$` + column + `$1</c:f>`)
+		buf.WriteString(stringCache([]string{s.Name}))
+		buf.WriteString(`</c:strRef>`)
+		buf.WriteString(`</c:tx>`)
+		buf.WriteString(c.seriesShape(s.Color))
+
+		switch c.Type {
+		case ChartTypeBar:
+			buf.WriteString(`<c:invertIfNegative val="0"/>`)
+		case ChartTypeLine, ChartTypeScatter:
+			buf.WriteString(`<c:marker><c:symbol val="circle"/><c:size val="5"/></c:marker>`)
+		case ChartTypePie:
+			for point, color := range s.PointColors {
+				if color == "" {
+					continue
+				}
+
+				buf.WriteString(`<c:dPt>`)
+				buf.WriteString(`<c:idx val="` + strconv.Itoa(point) + `"/>`)
+				buf.WriteString(`<c:bubble3D val="0"/>`)
+				buf.WriteString(`<c:spPr><a:solidFill><a:srgbClr val="` + color + `"/></a:solidFill></c:spPr>`)
+				buf.WriteString(`</c:dPt>`)
+			}
+		}
+
+		if c.Type == ChartTypeScatter {
+			seriesLastRow := strconv.Itoa(len(s.Values) + 1)
+
+			buf.WriteString(`<c:xVal>`)
+			buf.WriteString(numberReference(`Sheet1!$`+xColumn+`$2:$`+xColumn+`$`+seriesLastRow, s.XValues))
+			buf.WriteString(`</c:xVal>`)
+			buf.WriteString(`<c:yVal>`)
+			buf.WriteString(numberReference(`Sheet1!$`+column+`$2:$`+column+`$`+seriesLastRow, s.Values))
+			buf.WriteString(`</c:yVal>`)
+			buf.WriteString(`<c:smooth val="0"/>`)
+		} else {
+			buf.WriteString(`<c:cat>`)
+			buf.WriteString(`<c:strRef>`)
+			buf.WriteString(`<c:f>Sheet1!$A$2:$A$` + lastRow + `</c:f>`)
+			buf.WriteString(stringCache(c.Categories))
+			buf.WriteString(`</c:strRef>`)
+			buf.WriteString(`</c:cat>`)
+			buf.WriteString(`<c:val>`)
+			buf.WriteString(numberReference(`Sheet1!$`+column+`$2:$`+column+`$`+lastRow, s.Values))
+			buf.WriteString(`</c:val>`)
+
+			if c.Type == ChartTypeLine {
+				buf.WriteString(`<c:smooth val="0"/>`)
+			}
+		}
+
+		buf.WriteString(`</c:ser>`)
+	}
+
+	return buf.String()
+}
+
+func (c *Chart) seriesShape(color string) string {
+	if color == "" || c.Type == ChartTypePie {
+		return ""
+	}
+
+	fill := `<a:solidFill><a:srgbClr val="` + color + `"/></a:solidFill>`
+
+	if c.Type == ChartTypeLine || c.Type == ChartTypeScatter {
+		return `<c:spPr><a:ln w="28575" cap="rnd">` + fill + `</a:ln></c:spPr>`
+	}
+
+	return `<c:spPr>` + fill + `</c:spPr>`
+}
+
+func (c *Chart) axes() string {
+	if c.Type == ChartTypePie {
+		return ""
+	}
+
+	categoryPosition, valuePosition := "b", "l"
+	if c.IsHorisontal && c.Type == ChartTypeBar {
+		categoryPosition, valuePosition = "l", "b"
+	}
+
+	var buf bytes.Buffer
+
+	if c.Type == ChartTypeScatter {
+		buf.WriteString(valueAxis(valueAxisArgs{
+			id:        chartCategoryAxisID,
+			crossAxis: chartValueAxisID,
+			position:  categoryPosition,
+			title:     c.XAxisTitle,
+		}))
+	} else {
+		buf.WriteString(`<c:catAx>`)
+		buf.WriteString(`<c:axId val="` + chartCategoryAxisID + `"/>`)
+		buf.WriteString(`<c:scaling><c:orientation val="minMax"/></c:scaling>`)
+		buf.WriteString(`<c:delete val="0"/>`)
+		buf.WriteString(`<c:axPos val="` + categoryPosition + `"/>`)
+
+		if c.XAxisTitle != "" {
+			buf.WriteString(chartTitle(c.XAxisTitle))
+		}
+
+		buf.WriteString(`<c:numFmt formatCode="General" sourceLinked="1"/>`)
+		buf.WriteString(`<c:majorTickMark val="out"/>`)
+		buf.WriteString(`<c:minorTickMark val="none"/>`)
+		buf.WriteString(`<c:tickLblPos val="nextTo"/>`)
+		buf.WriteString(`<c:crossAx val="` + chartValueAxisID + `"/>`)
+		buf.WriteString(`<c:crosses val="autoZero"/>`)
+		buf.WriteString(`<c:auto val="1"/>`)
+		buf.WriteString(`<c:lblAlgn val="ctr"/>`)
+		buf.WriteString(`<c:lblOffset val="100"/>`)
+		buf.WriteString(`<c:noMultiLvlLbl val="0"/>`)
+		buf.WriteString(`</c:catAx>`)
+	}
+
+	buf.WriteString(valueAxis(valueAxisArgs{
+		id:        chartValueAxisID,
+		crossAxis: chartCategoryAxisID,
+		position:  valuePosition,
+		title:     c.YAxisTitle,
+		gridlines: true,
+	}))
+
+	return buf.String()
+}
+
+type valueAxisArgs struct {
+	id        string
+	crossAxis string
+	position  string
+	title     string
+	gridlines bool
+}
+
+func valueAxis(args valueAxisArgs) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<c:valAx>`)
+	buf.WriteString(`<c:axId val="` + args.id + `"/>`)
+	buf.WriteString(`<c:scaling><c:orientation val="minMax"/></c:scaling>`)
+	buf.WriteString(`<c:delete val="0"/>`)
+	buf.WriteString(`<c:axPos val="` + args.position + `"/>`)
+
+	if args.gridlines {
+		buf.WriteString(`<c:majorGridlines/>`)
+	}
+
+	if args.title != "" {
+		buf.WriteString(chartTitle(args.title))
+	}
+
+	buf.WriteString(`<c:numFmt formatCode="General" sourceLinked="1"/>`)
+	buf.WriteString(`<c:majorTickMark val="out"/>`)
+	buf.WriteString(`<c:minorTickMark val="none"/>`)
+	buf.WriteString(`<c:tickLblPos val="nextTo"/>`)
+	buf.WriteString(`<c:crossAx val="` + args.crossAxis + `"/>`)
+	buf.WriteString(`<c:crosses val="autoZero"/>`)
+	buf.WriteString(`<c:crossBetween val="between"/>`)
+	buf.WriteString(`</c:valAx>`)
+
+	return buf.String()
+}
+
+func chartTitle(title string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<c:title>`)
+	buf.WriteString(`<c:tx>`)
+	buf.WriteString(`<c:rich>`)
+	buf.WriteString(`<a:bodyPr/>`)
+	buf.WriteString(`<a:lstStyle/>`)
+	buf.WriteString(`<a:p><a:r><a:t>`)
+	xml.EscapeText(&buf, []byte(title))
+	buf.WriteString(`</a:t></a:r></a:p>`)
+	buf.WriteString(`</c:rich>`)
+	buf.WriteString(`</c:tx>`)
+	buf.WriteString(`<c:overlay val="0"/>`)
+	buf.WriteString(`</c:title>`)
+
+	return buf.String()
+}
+
+func stringCache(values []string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<c:strCache>`)
+	buf.WriteString(`<c:ptCount val="` + strconv.Itoa(len(values)) + `"/>`)
+
+	for index, value := range values {
+		buf.WriteString(`<c:pt idx="` + strconv.Itoa(index) + `"><c:v>`)
+		xml.EscapeText(&buf, []byte(value))
+		buf.WriteString(`</c:v></c:pt>`)
+	}
+
+	buf.WriteString(`</c:strCache>`)
+
+	return buf.String()
+}
+
+func numberReference(formula string, values []float64) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<c:numRef>`)
+	buf.WriteString(`<c:f>` + formula + `</c:f>`)
+	buf.WriteString(`<c:numCache>`)
+	buf.WriteString(`<c:formatCode>General</c:formatCode>`)
+	buf.WriteString(`<c:ptCount val="` + strconv.Itoa(len(values)) + `"/>`)
+
+	for index, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+
+		buf.WriteString(`<c:pt idx="` + strconv.Itoa(index) + `"><c:v>` + formatChartValue(value) + `</c:v></c:pt>`)
+	}
+
+	buf.WriteString(`</c:numCache>`)
+	buf.WriteString(`</c:numRef>`)
+
+	return buf.String()
+}
+
+func formatChartValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func spreadsheetColumn(index int) string {
+	var name string
+
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+
+	return name
+}
+
+// seriesColumns returns the workbook columns of the categories or X values
+// and of the values of a series. Scatter series have X values of their own,
+// so each one gets a pair of columns.
+func (c *Chart) seriesColumns(index int) (string, string) {
+	if c.Type == ChartTypeScatter {
+		return spreadsheetColumn(index * 2), spreadsheetColumn(index*2 + 1)
+	}
+
+	return "A", spreadsheetColumn(index + 1)
+}
+
+// The embedded workbook holds the chart data so the chart stays editable
+// in Word: column A has the categories and every series gets its own column
+// with the name in the first row, scatter series have their X values in the
+// column before.
+func (c *Chart) workbook() ([]byte, error) {
+	var sheet bytes.Buffer
+
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sheet.WriteString(`<sheetData>`)
+	sheet.WriteString(`<row r="1">`)
+
+	for index, s := range c.Series {
+		_, column := c.seriesColumns(index)
+		sheet.WriteString(stringCell(column+"1", s.Name))
+	}
+
+	sheet.WriteString(`</row>`)
+
+	for row := 0; row < c.rowsCount(); row++ {
+		rowNumber := strconv.Itoa(row + 2)
+
+		sheet.WriteString(`<row r="` + rowNumber + `">`)
+
+		if c.Type != ChartTypeScatter {
+			sheet.WriteString(stringCell("A"+rowNumber, c.Categories[row]))
+		}
+
+		for index, s := range c.Series {
+			xColumn, column := c.seriesColumns(index)
+
+			if c.Type == ChartTypeScatter && len(s.XValues) > row {
+				sheet.WriteString(numberCell(xColumn+rowNumber, s.XValues[row]))
+			}
+
+			if len(s.Values) > row && !math.IsNaN(s.Values[row]) {
+				sheet.WriteString(numberCell(column+rowNumber, s.Values[row]))
+			}
+		}
+
+		sheet.WriteString(`</row>`)
+	}
+
+	sheet.WriteString(`</sheetData>`)
+	sheet.WriteString(`</worksheet>`)
+
+	files := []*templateFile{
+		{
+			name:  "[Content_Types].xml",
+			bytes: []byte(templateWorkbookContentTypes),
+		},
+		{
+			name:     ".rels",
+			savePath: "_rels",
+			bytes:    []byte(templateWorkbookRels),
+		},
+		{
+			name:     "workbook.xml",
+			savePath: "xl",
+			bytes:    []byte(templateWorkbook),
+		},
+		{
+			name:     "workbook.xml.rels",
+			savePath: "xl/_rels",
+			bytes:    []byte(templateWorkbookWorkbookRels),
+		},
+		{
+			name:     "sheet1.xml",
+			savePath: "xl/worksheets",
+			bytes:    sheet.Bytes(),
+		},
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		f, err := writer.Create(file.FullName())
+		if err != nil {
+			return nil, errors.Wrap(err, "writer.Create")
+		}
+
+		if _, err := f.Write(file.bytes); err != nil {
+			return nil, errors.Wrap(err, "file.Write")
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "writer.Close")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func stringCell(ref string, value string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t>`)
+	xml.EscapeText(&buf, []byte(value))
+	buf.WriteString(`</t></is></c>`)
+
+	return buf.String()
+}
+
+func numberCell(ref string, value float64) string {
+	return `<c r="` + ref + `"><v>` + formatChartValue(value) + `</v></c>`
+}
+
+func (c *Chart) chartPart() []byte {
+	if c.part != nil {
+		return c.part
+	}
+
+	return []byte(c.xml())
+}
+
+type writeChartFilesArgs struct {
+	charts []*Chart
+	writer *zip.Writer
+}
+
+func writeChartFiles(args writeChartFilesArgs) error {
+	for i, c := range args.charts {
+		number := i + 1
+
+		chartFile, err := args.writer.Create("word/charts/" + chartFileName(number))
+		if err != nil {
+			return errors.Wrap(err, "writer.Create")
+		}
+
+		if _, err := chartFile.Write(c.chartPart()); err != nil {
+			return errors.Wrap(err, "chartFile.Write")
+		}
+
+		relsFile, err := args.writer.Create("word/charts/_rels/" + chartFileName(number) + ".rels")
+		if err != nil {
+			return errors.Wrap(err, "writer.Create")
+		}
+
+		var rels bytes.Buffer
+		rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+		rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+		rels.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/package" Target="../embeddings/` + chartWorkbookName(number) + `"/>`)
+		rels.WriteString(`</Relationships>`)
+
+		if _, err := relsFile.Write(rels.Bytes()); err != nil {
+			return errors.Wrap(err, "relsFile.Write")
+		}
+
+		workbook := c.workbookPart
+
+		if workbook == nil {
+			workbook, err = c.workbook()
+			if err != nil {
+				return errors.Wrap(err, "c.workbook")
+			}
+		}
+
+		workbookFile, err := args.writer.Create("word/embeddings/" + chartWorkbookName(number))
+		if err != nil {
+			return errors.Wrap(err, "writer.Create")
+		}
+
+		if _, err := workbookFile.Write(workbook); err != nil {
+			return errors.Wrap(err, "workbookFile.Write")
+		}
+	}
+
+	return nil
+}
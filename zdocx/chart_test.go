@@ -0,0 +1,140 @@
+package zdocx
+
+import (
+	"strings"
+	"testing"
+)
+
+func chartParts(t *testing.T, chart *Chart) (string, string) {
+	t.Helper()
+
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Chart: chart}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, buf.Bytes())
+	workbook := zipEntries(t, entries["word/embeddings/Microsoft_Excel_Worksheet1.xlsx"])
+
+	return string(entries["word/charts/chart1.xml"]), string(workbook["xl/worksheets/sheet1.xml"])
+}
+
+func TestChartWorkbook(t *testing.T) {
+	chart, sheet := chartParts(t, &Chart{
+		Type:       ChartTypeBar,
+		Categories: []string{"Q1", "Q2"},
+		Series: []*ChartSeries{
+			{Name: "North", Values: []float64{1, 2}},
+			{Name: "South", Values: []float64{3, 4}},
+		},
+	})
+
+	for _, expected := range []string{
+		`<c:f>Sheet1!$A$2:$A$3</c:f>`,
+		`<c:f>Sheet1!$B$2:$B$3</c:f>`,
+		`<c:f>Sheet1!$C$2:$C$3</c:f>`,
+	} {
+		if !strings.Contains(chart, expected) {
+			t.Errorf("no %s in the chart", expected)
+		}
+	}
+
+	for _, expected := range []string{
+		`<c r="B1" t="inlineStr"><is><t>North</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t>Q1</t></is></c>`,
+		`<c r="C3"><v>4</v></c>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("no %s in the workbook", expected)
+		}
+	}
+}
+
+func TestScatterChartXValues(t *testing.T) {
+	chart, sheet := chartParts(t, &Chart{
+		Type: ChartTypeScatter,
+		Series: []*ChartSeries{
+			{Name: "A", XValues: []float64{1, 2, 3}, Values: []float64{10, 20, 30}},
+			{Name: "B", XValues: []float64{5, 7}, Values: []float64{50, 70}},
+		},
+	})
+
+	for _, expected := range []string{
+		`<c:xVal><c:numRef><c:f>Sheet1!$A$2:$A$4</c:f>`,
+		`<c:yVal><c:numRef><c:f>Sheet1!$B$2:$B$4</c:f>`,
+		`<c:xVal><c:numRef><c:f>Sheet1!$C$2:$C$3</c:f>`,
+		`<c:yVal><c:numRef><c:f>Sheet1!$D$2:$D$3</c:f>`,
+	} {
+		if !strings.Contains(chart, expected) {
+			t.Errorf("no %s in the chart", expected)
+		}
+	}
+
+	for _, expected := range []string{
+		`<c r="B1" t="inlineStr"><is><t>A</t></is></c><c r="D1" t="inlineStr"><is><t>B</t></is></c>`,
+		`<row r="2"><c r="A2"><v>1</v></c><c r="B2"><v>10</v></c><c r="C2"><v>5</v></c><c r="D2"><v>50</v></c></row>`,
+		`<row r="4"><c r="A4"><v>3</v></c><c r="B4"><v>30</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("no %s in the workbook", expected)
+		}
+	}
+}
+
+func TestChartError(t *testing.T) {
+	for name, chart := range map[string]*Chart{
+		"type":      {Type: "radar", Series: []*ChartSeries{{Values: []float64{1}}}},
+		"no series": {Type: ChartTypeBar},
+		"x values":  {Type: ChartTypeScatter, Series: []*ChartSeries{{XValues: []float64{1}, Values: []float64{1, 2}}}},
+	} {
+		if err := chart.Error(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestChartWrittenTwice(t *testing.T) {
+	chart := &Chart{Type: ChartTypePie, Series: []*ChartSeries{{Values: []float64{1, 2}}}}
+	doc := NewDocument(NewDocumentArgs{})
+
+	for i := 0; i < 2; i++ {
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Chart: chart}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, buf.Bytes())
+	document := string(entries["word/document.xml"])
+	rels := string(entries["word/_rels/document.xml.rels"])
+
+	if _, ok := entries["word/charts/chart2.xml"]; ok {
+		t.Error("the chart part is written twice")
+	}
+
+	if _, ok := entries["word/charts/chart1.xml"]; !ok {
+		t.Error("no chart part")
+	}
+
+	if count := strings.Count(document, `r:id="`+chartRelsID(1)+`"`); count != 2 {
+		t.Errorf("%d drawings refer to the chart, expected 2", count)
+	}
+
+	if count := strings.Count(rels, `Id="`+chartRelsID(1)+`"`); count != 1 {
+		t.Errorf("%d chart relationships, expected 1", count)
+	}
+
+	if !strings.Contains(document, `<wp:docPr id="1" `) || !strings.Contains(document, `<wp:docPr id="2" `) {
+		t.Error("the drawings do not get their own ids")
+	}
+}
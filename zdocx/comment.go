@@ -0,0 +1,261 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	commentsID                 = "fileCommentsID"
+	commentsExtendedID         = "fileCommentsExtendedID"
+	commentTextStyleClass      = "CommentText"
+	commentReferenceStyleClass = "CommentReference"
+)
+
+type Comment struct {
+	Author     string
+	Initials   string
+	Date       time.Time
+	Content    []interface{}
+	Replies    []*Comment
+	IsResolved bool
+}
+
+func (c *Comment) Error() error {
+	if c.Author == "" {
+		return errors.New("no Comment.Author")
+	}
+
+	return nil
+}
+
+func commentParaID(id int) string {
+	return fmt.Sprintf("%08X", id+1)
+}
+
+// thread returns the comment followed by all of its replies, they share the
+// anchored range in the document.
+func (c *Comment) thread() []*Comment {
+	comments := []*Comment{c}
+
+	for _, reply := range c.Replies {
+		comments = append(comments, reply.thread()...)
+	}
+
+	return comments
+}
+
+// commentsPart keeps the ids of the comments written to the document, the
+// same Comment can be written to other documents too. Ranges that started
+// and did not end yet are open.
+type commentsPart struct {
+	partRelations
+	comments []*Comment
+	ids      map[*Comment]int
+	parents  map[*Comment]*Comment
+	open     map[*Comment]bool
+	content  bytes.Buffer
+}
+
+func (part *commentsPart) hasExtended() bool {
+	for _, c := range part.comments {
+		if part.parents[c] != nil || c.IsResolved {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *Document) commentsError() error {
+	if d.comments == nil {
+		return nil
+	}
+
+	for _, c := range d.comments.comments {
+		if d.comments.open[c] {
+			return errors.New("comment range of " + c.Author + " is not closed")
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) commentsPart() *commentsPart {
+	if d.comments == nil {
+		d.comments = &commentsPart{
+			partRelations: partRelations{
+				fileName: "comments",
+			},
+			ids:     map[*Comment]int{},
+			parents: map[*Comment]*Comment{},
+			open:    map[*Comment]bool{},
+		}
+	}
+
+	return d.comments
+}
+
+func (d *Document) registerComment(c *Comment, parent *Comment) error {
+	if d.currentPart != nil {
+		return errors.New("comments are not supported in headers, footers, notes and comments")
+	}
+
+	part := d.commentsPart()
+
+	if _, ok := part.ids[c]; ok {
+		return nil
+	}
+
+	if err := c.Error(); err != nil {
+		return err
+	}
+
+	id := len(part.comments)
+	part.ids[c] = id
+	part.parents[c] = parent
+	part.comments = append(part.comments, c)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<w:comment w:id="` + strconv.Itoa(id) + `" w:author="` + escapeAttr(c.Author) + `"`)
+
+	if !c.Date.IsZero() {
+		buf.WriteString(` w:date="` + c.Date.UTC().Format("2006-01-02T15:04:05Z") + `"`)
+	}
+
+	if c.Initials != "" {
+		buf.WriteString(` w:initials="` + escapeAttr(c.Initials) + `"`)
+	}
+
+	buf.WriteString(`>`)
+
+	if err := d.writeAnnotation(&buf, writeAnnotationArgs{
+		part:       &part.partRelations,
+		content:    c.Content,
+		styleClass: commentTextStyleClass,
+		mark:       `<w:r><w:rPr><w:rStyle w:val="` + commentReferenceStyleClass + `"/></w:rPr><w:annotationRef/></w:r>` + getSpace(),
+		paraID:     commentParaID(id),
+	}); err != nil {
+		return errors.Wrap(err, "d.writeAnnotation")
+	}
+
+	buf.WriteString(`</w:comment>`)
+	part.content.Write(buf.Bytes())
+
+	for _, reply := range c.Replies {
+		if err := d.registerComment(reply, c); err != nil {
+			return errors.Wrap(err, "d.registerComment")
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) writeCommentRangeStart(w xmlWriter, c *Comment) error {
+	if err := d.registerComment(c, nil); err != nil {
+		return errors.Wrap(err, "d.registerComment")
+	}
+
+	part := d.commentsPart()
+	part.open[c] = true
+
+	for _, i := range c.thread() {
+		w.WriteString(`<w:commentRangeStart w:id="` + strconv.Itoa(part.ids[i]) + `"/>`)
+	}
+
+	return nil
+}
+
+func (d *Document) writeCommentRangeEnd(w xmlWriter, c *Comment) error {
+	if err := d.registerComment(c, nil); err != nil {
+		return errors.Wrap(err, "d.registerComment")
+	}
+
+	part := d.commentsPart()
+
+	if !part.open[c] {
+		return errors.New("comment range of " + c.Author + " ends before it starts")
+	}
+
+	part.open[c] = false
+
+	for _, i := range c.thread() {
+		id := strconv.Itoa(part.ids[i])
+
+		w.WriteString(`<w:commentRangeEnd w:id="` + id + `"/>`)
+		w.WriteString(`<w:r><w:rPr><w:rStyle w:val="` + commentReferenceStyleClass + `"/></w:rPr><w:commentReference w:id="` + id + `"/></w:r>`)
+	}
+
+	return nil
+}
+
+type writeCommentFilesArgs struct {
+	document *Document
+	writer   *zip.Writer
+}
+
+func writeCommentFiles(args writeCommentFilesArgs) error {
+	part := args.document.comments
+	if part == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(getDocumentStartTags("comments"))
+	buf.Write(part.content.Bytes())
+	buf.WriteString(`</w:comments>`)
+
+	file, err := args.writer.Create("word/comments.xml")
+	if err != nil {
+		return errors.Wrap(err, "writer.Create")
+	}
+
+	_, err = file.Write(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "file.Write")
+	}
+
+	if err := writePartRelsFile(writePartRelsFileArgs{
+		part:   &part.partRelations,
+		writer: args.writer,
+	}); err != nil {
+		return errors.Wrap(err, "writePartRelsFile")
+	}
+
+	if !part.hasExtended() {
+		return nil
+	}
+
+	buf.Reset()
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	buf.WriteString(`<w15:commentsEx xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" xmlns:w15="http://schemas.microsoft.com/office/word/2012/wordml" mc:Ignorable="w15">`)
+
+	for _, c := range part.comments {
+		buf.WriteString(`<w15:commentEx w15:paraId="` + commentParaID(part.ids[c]) + `"`)
+
+		if parent := part.parents[c]; parent != nil {
+			buf.WriteString(` w15:paraIdParent="` + commentParaID(part.ids[parent]) + `"`)
+		}
+
+		buf.WriteString(` w15:done="` + boolToOnOff(c.IsResolved) + `"/>`)
+	}
+
+	buf.WriteString(`</w15:commentsEx>`)
+
+	file, err = args.writer.Create("word/commentsExtended.xml")
+	if err != nil {
+		return errors.Wrap(err, "writer.Create")
+	}
+
+	_, err = file.Write(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "file.Write")
+	}
+
+	return nil
+}
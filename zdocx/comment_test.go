@@ -0,0 +1,119 @@
+package zdocx
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testComment() *Comment {
+	return &Comment{
+		Author:  "Ann",
+		Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "Check the numbers"}}}},
+		Replies: []*Comment{
+			{Author: "Bob", Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "Done"}}}}, IsResolved: true},
+		},
+	}
+}
+
+func writeCommentRange(t *testing.T, c *Comment) map[string][]byte {
+	t.Helper()
+
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "first", CommentStart: c}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "second", CommentEnd: c}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return zipEntries(t, data.Bytes())
+}
+
+func TestCommentRangeAcrossParagraphs(t *testing.T) {
+	entries := writeCommentRange(t, testComment())
+	body := string(entries["word/document.xml"])
+
+	paragraphs := regexp.MustCompile(`<w:p>.*?</w:p>`).FindAllString(body, -1)
+	if len(paragraphs) < 2 {
+		t.Fatalf("%d paragraphs", len(paragraphs))
+	}
+
+	for _, expected := range []string{`<w:commentRangeStart w:id="0"/>`, `<w:commentRangeStart w:id="1"/>`} {
+		if !strings.Contains(paragraphs[0], expected) {
+			t.Errorf("no %s in the first paragraph", expected)
+		}
+	}
+
+	for _, expected := range []string{`<w:commentRangeEnd w:id="0"/>`, `<w:commentReference w:id="0"/>`, `<w:commentRangeEnd w:id="1"/>`, `<w:commentReference w:id="1"/>`} {
+		if !strings.Contains(paragraphs[1], expected) {
+			t.Errorf("no %s in the second paragraph", expected)
+		}
+	}
+
+	comments := string(entries["word/comments.xml"])
+
+	for _, expected := range []string{`<w:comment w:id="0" w:author="Ann">`, `<w:comment w:id="1" w:author="Bob">`, `Check the numbers`, `w14:paraId="00000002"`} {
+		if !strings.Contains(comments, expected) {
+			t.Errorf("no %s in comments.xml", expected)
+		}
+	}
+
+	extended := string(entries["word/commentsExtended.xml"])
+
+	for _, expected := range []string{
+		`<w15:commentEx w15:paraId="00000001" w15:done="0"/>`,
+		`<w15:commentEx w15:paraId="00000002" w15:paraIdParent="00000001" w15:done="1"/>`,
+	} {
+		if !strings.Contains(extended, expected) {
+			t.Errorf("no %s in commentsExtended.xml", expected)
+		}
+	}
+
+	if !strings.Contains(string(entries["word/_rels/document.xml.rels"]), `Target="commentsExtended.xml"`) {
+		t.Error("commentsExtended.xml is not related")
+	}
+}
+
+func TestCommentInTwoDocuments(t *testing.T) {
+	c := testComment()
+
+	for index := 0; index < 2; index++ {
+		entries := writeCommentRange(t, c)
+
+		if !strings.Contains(string(entries["word/comments.xml"]), `<w:comment w:id="0" w:author="Ann">`) {
+			t.Errorf("document %d has no comment", index)
+		}
+	}
+}
+
+func TestCommentRangeErrors(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "open", CommentStart: testComment()}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := doc.WriteToBuffer(); err == nil {
+		t.Error("document with an open comment range written")
+	}
+
+	doc = NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "end", CommentEnd: testComment()}}}); err == nil {
+		t.Error("comment range ended before it started")
+	}
+
+	doc = NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "text", Comment: &Comment{}}}}); err == nil {
+		t.Error("comment without an author")
+	}
+}
@@ -0,0 +1,200 @@
+package zdocx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Parts that are derived from word/document.xml are compared through its
+// content, not byte by byte.
+var derivedParts = map[string]bool{
+	"word/document.xml":            true,
+	"word/_rels/document.xml.rels": true,
+	"[Content_Types].xml":          true,
+}
+
+// The timestamps written by writeCorePropertiesFile change on every save.
+var volatileCoreProperties = regexp.MustCompile(`<dcterms:(created|modified)[^>]*>[^<]*</dcterms:(created|modified)>`)
+
+type Comparison struct {
+	Differences []Difference
+	before      *documentContent
+	after       *documentContent
+}
+
+func (c *Comparison) IsEqual() bool {
+	return len(c.Differences) == 0
+}
+
+// Compare compares two documents written by this package. The body is read
+// back and compared item by item together with its images and charts, other
+// parts such as headers, footers, notes and comments are compared whole and
+// reported as DifferencePart.
+func Compare(a []byte, b []byte) (*Comparison, error) {
+	before, err := openPackage(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "openPackage")
+	}
+
+	after, err := openPackage(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "openPackage")
+	}
+
+	beforeContent, err := readDocumentContent(before)
+	if err != nil {
+		return nil, errors.Wrap(err, "readDocumentContent")
+	}
+
+	afterContent, err := readDocumentContent(after)
+	if err != nil {
+		return nil, errors.Wrap(err, "readDocumentContent")
+	}
+
+	df := differ{}
+	df.items(beforeContent.items, afterContent.items, "body", "body")
+
+	return &Comparison{
+		Differences: append(df.differences, compareParts(before, after)...),
+		before:      beforeContent,
+		after:       afterContent,
+	}, nil
+}
+
+func CompareFiles(a string, b string) (*Comparison, error) {
+	before, err := ioutil.ReadFile(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadFile")
+	}
+
+	after, err := ioutil.ReadFile(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadFile")
+	}
+
+	comparison, err := Compare(before, after)
+	if err != nil {
+		return nil, errors.Wrap(err, "Compare")
+	}
+
+	return comparison, nil
+}
+
+func compareParts(before *docxPackage, after *docxPackage) []Difference {
+	var differences []Difference
+
+	names := map[string]bool{}
+
+	for _, name := range append(append([]string{}, before.names...), after.names...) {
+		if derivedParts[name] || isBodyPart(name) {
+			continue
+		}
+
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		beforeContent, inBefore := before.files[name]
+		afterContent, inAfter := after.files[name]
+
+		switch {
+		case !inBefore:
+			differences = append(differences, Difference{Kind: DifferencePart, Change: ChangeAdded, Path: name})
+		case !inAfter:
+			differences = append(differences, Difference{Kind: DifferencePart, Change: ChangeRemoved, Path: name})
+		case !bytes.Equal(normalizePart(name, beforeContent), normalizePart(name, afterContent)):
+			differences = append(differences, Difference{Kind: DifferencePart, Change: ChangeModified, Path: name})
+		}
+	}
+
+	return differences
+}
+
+// Images and charts are compared where the body refers to them.
+func isBodyPart(name string) bool {
+	for _, prefix := range []string{"word/media/", "word/charts/", "word/embeddings/"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizePart(name string, content []byte) []byte {
+	if name == "docProps/core.xml" {
+		return volatileCoreProperties.ReplaceAll(content, nil)
+	}
+
+	return content
+}
+
+type RedlineArgs struct {
+	Author string
+	Date   time.Time
+}
+
+func (args *RedlineArgs) error() error {
+	if args.Author == "" {
+		return errors.New("no args.Author")
+	}
+
+	return nil
+}
+
+// Redline writes the newer document with everything that changed since the
+// older one shown as tracked changes by args.Author. Only the body, with its
+// images and charts, and the page setup are carried over, the redline has no
+// headers, footers, notes or comments.
+func (c *Comparison) Redline(args RedlineArgs) (*bytes.Buffer, error) {
+	if err := args.error(); err != nil {
+		return nil, err
+	}
+
+	items, err := Diff(DiffArgs{
+		Old:    c.before.items,
+		New:    c.after.items,
+		Author: args.Author,
+		Date:   args.Date,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Diff")
+	}
+
+	newDocumentArgs := NewDocumentArgs{}
+
+	if c.after.section != nil && !c.after.section.margins.IsEmpty() {
+		newDocumentArgs.Margins = &c.after.section.margins
+	}
+
+	doc := NewDocument(newDocumentArgs)
+
+	if c.after.section != nil {
+		doc.PageSize = c.after.section.pageSize
+		doc.PageOrientation = c.after.section.orientation
+	}
+
+	if err := doc.setItems(items); err != nil {
+		return nil, errors.Wrap(err, "doc.setItems")
+	}
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		return nil, errors.Wrap(err, "doc.WriteToBuffer")
+	}
+
+	return buf, nil
+}
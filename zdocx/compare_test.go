@@ -0,0 +1,336 @@
+package zdocx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type compareVersion struct {
+	header  string
+	runs    []*Text
+	cell    string
+	image   []byte
+	removed bool
+	added   bool
+}
+
+func compareDocument(t *testing.T, version compareVersion) []byte {
+	t.Helper()
+
+	doc := NewDocument(NewDocumentArgs{})
+	doc.Header = []*Paragraph{{Texts: []*Text{{Text: version.header}}}}
+
+	items := []interface{}{
+		&Paragraph{Texts: []*Text{{Text: "Report"}}},
+		&Paragraph{Texts: version.runs},
+	}
+
+	if !version.removed {
+		items = append(items, &Paragraph{Texts: []*Text{{Text: "Paragraph removed later"}}})
+	}
+
+	items = append(items,
+		&Table{
+			Grid: []int{3000, 3000},
+			TR: []*TR{
+				{TD: []*TD{
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "Name"}}}}},
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "Value"}}}}},
+				}},
+				{TD: []*TD{
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "Total"}}}}},
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: version.cell}}}}},
+				}},
+			},
+		},
+		&Paragraph{Texts: []*Text{{Image: &Image{Bytes: version.image, FileName: "chart.png"}}}},
+	)
+
+	if version.added {
+		items = append(items, &Paragraph{Texts: []*Text{{Text: "Paragraph added"}}})
+	}
+
+	if err := doc.setItems(items); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data.Bytes()
+}
+
+func compareVersions(t *testing.T) (compareVersion, compareVersion) {
+	before := compareVersion{
+		header: "Draft",
+		runs:   []*Text{{Text: "Sales"}, {Text: "grew"}, {Text: "slowly"}},
+		cell:   "10",
+		image:  testPNG(t, 2, 2),
+	}
+
+	after := compareVersion{
+		header:  "Final",
+		runs:    []*Text{{Text: "Sales", Style: TextStyle{IsBold: true}}, {Text: "grew"}, {Text: "fast"}},
+		cell:    "12",
+		image:   testPNG(t, 3, 3),
+		removed: true,
+		added:   true,
+	}
+
+	return before, after
+}
+
+func mediaName(data []byte) string {
+	sum := sha1.Sum(data)
+
+	return "image_" + hex.EncodeToString(sum[:]) + ".png"
+}
+
+func TestCompare(t *testing.T) {
+	before, after := compareVersions(t)
+
+	comparison, err := Compare(compareDocument(t, before), compareDocument(t, after))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Difference{
+		{Kind: DifferenceRun, Change: ChangeFormatted, Path: "body/1/run/0", Before: "Sales", After: "Sales"},
+		{Kind: DifferenceRun, Change: ChangeRemoved, Path: "body/1/run/2", Before: "slowly"},
+		{Kind: DifferenceRun, Change: ChangeAdded, Path: "body/1/run/2", After: "fast"},
+		{Kind: DifferenceParagraph, Change: ChangeRemoved, Path: "body/2", Before: "Paragraph removed later"},
+		{Kind: DifferenceCell, Change: ChangeModified, Path: "body/2/row/1/cell/1", Before: "10", After: "12"},
+		{Kind: DifferenceRun, Change: ChangeRemoved, Path: "body/3/row/1/cell/1/0/run/0", Before: "10"},
+		{Kind: DifferenceRun, Change: ChangeAdded, Path: "body/2/row/1/cell/1/0/run/0", After: "12"},
+		{Kind: DifferenceImage, Change: ChangeRemoved, Path: "body/4/run/0", Before: mediaName(before.image)},
+		{Kind: DifferenceImage, Change: ChangeAdded, Path: "body/3/run/0", After: mediaName(after.image)},
+		{Kind: DifferenceParagraph, Change: ChangeAdded, Path: "body/4", After: "Paragraph added"},
+		{Kind: DifferencePart, Change: ChangeModified, Path: "word/header1.xml"},
+	}
+
+	if !reflect.DeepEqual(comparison.Differences, expected) {
+		t.Errorf("differences:\n%#v\nexpected:\n%#v", comparison.Differences, expected)
+	}
+
+	same, err := Compare(compareDocument(t, before), compareDocument(t, before))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !same.IsEqual() {
+		t.Errorf("a document differs from itself: %#v", same.Differences)
+	}
+}
+
+func TestReadDocumentContent(t *testing.T) {
+	_, after := compareVersions(t)
+
+	pkg, err := openPackage(compareDocument(t, after))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := readDocumentContent(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds, texts []string
+
+	for _, item := range content.items {
+		kinds = append(kinds, itemKind(item))
+		texts = append(texts, itemText(item))
+	}
+
+	expectedKinds := []string{DifferenceParagraph, DifferenceParagraph, DifferenceTable, DifferenceImage, DifferenceParagraph}
+	expectedTexts := []string{"Report", "Sales grew fast", "Name\tValue\nTotal\t12", mediaName(after.image), "Paragraph added"}
+
+	if !reflect.DeepEqual(kinds, expectedKinds) || !reflect.DeepEqual(texts, expectedTexts) {
+		t.Fatalf("read %q %q", kinds, texts)
+	}
+
+	if !content.items[1].(*Paragraph).Texts[0].Style.IsBold {
+		t.Error("bold run read as regular")
+	}
+
+	if table := content.items[2].(*Table); !reflect.DeepEqual(table.Grid, []int{3000, 3000}) {
+		t.Errorf("table grid %v", table.Grid)
+	}
+
+	if img := content.items[3].(*Paragraph).Texts[0].Image; !bytes.Equal(img.Bytes, after.image) {
+		t.Error("image bytes differ")
+	}
+
+	if content.section == nil || content.section.pageSize.Width != PageSizeLetter().Width {
+		t.Errorf("section %+v", content.section)
+	}
+}
+
+func TestRedline(t *testing.T) {
+	before, after := compareVersions(t)
+	afterData := compareDocument(t, after)
+
+	comparison, err := Compare(compareDocument(t, before), afterData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := comparison.Redline(RedlineArgs{}); err == nil {
+		t.Error("Redline without an author")
+	}
+
+	redline, err := comparison.Redline(RedlineArgs{Author: "Ann", Date: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, redline.Bytes())
+	body := string(entries["word/document.xml"])
+
+	for _, expected := range []string{
+		`<w:del w:id="`,
+		`w:author="Ann" w:date="2024-05-01T00:00:00Z"`,
+		`<w:delText>slowly</w:delText>`,
+		`<w:delText>Paragraph removed later</w:delText>`,
+		`<w:delText>10</w:delText>`,
+		`<w:ins w:id="`,
+		`<w:rPrChange `,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("no %s in the redline", expected)
+		}
+	}
+
+	if _, ok := entries["word/header1.xml"]; ok {
+		t.Error("the redline has a header")
+	}
+
+	// Deleted revisions are left out when reading, so the redline reads back
+	// as the newer document.
+	reread, err := Compare(afterData, redline.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, difference := range reread.Differences {
+		if difference.Kind != DifferencePart {
+			t.Errorf("redline body differs from the newer document: %#v", difference)
+		}
+	}
+}
+
+func TestCompareResizedImage(t *testing.T) {
+	image := testPNG(t, 4, 4)
+
+	document := func(width int64) []byte {
+		doc := NewDocument(NewDocumentArgs{})
+
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: &Image{Bytes: image, Width: width, SizeUnit: SizeUnitMM}}}}); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := doc.WriteToBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return data.Bytes()
+	}
+
+	comparison, err := Compare(document(20), document(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Difference{
+		{Kind: DifferenceImage, Change: ChangeModified, Path: "body/0/run/0", Before: mediaName(image), After: mediaName(image)},
+	}
+
+	if !reflect.DeepEqual(comparison.Differences, expected) {
+		t.Fatalf("differences:\n%#v\nexpected:\n%#v", comparison.Differences, expected)
+	}
+
+	redline, err := comparison.Redline(RedlineArgs{Author: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := string(zipEntries(t, redline.Bytes())["word/document.xml"])
+
+	for _, expected := range []string{`<w:del w:id="`, `<w:ins w:id="`, `cx="719455"`, `cx="1439545"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("no %s in the redline", expected)
+		}
+	}
+}
+
+func TestRedlineChart(t *testing.T) {
+	sales := &Chart{Type: ChartTypeBar, Name: "Sales", Categories: []string{"Q1"}, Series: []*ChartSeries{{Name: "North", Values: []float64{1}}}}
+	costs := &Chart{Type: ChartTypePie, Name: "Costs", Series: []*ChartSeries{{Values: []float64{2, 3}}}}
+
+	document := func(charts ...*Chart) []byte {
+		doc := NewDocument(NewDocumentArgs{})
+
+		for _, chart := range charts {
+			if err := doc.SetP(&Paragraph{Texts: []*Text{{Chart: chart}}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := doc.WriteToBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return data.Bytes()
+	}
+
+	after := document(costs, sales)
+
+	comparison, err := Compare(document(sales), after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Difference{
+		{Kind: DifferenceParagraph, Change: ChangeAdded, Path: "body/0", After: "Costs"},
+	}
+
+	if !reflect.DeepEqual(comparison.Differences, expected) {
+		t.Fatalf("differences:\n%#v\nexpected:\n%#v", comparison.Differences, expected)
+	}
+
+	redline, err := comparison.Redline(RedlineArgs{Author: "Ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, redline.Bytes())
+	afterEntries := zipEntries(t, after)
+
+	for _, name := range []string{"word/charts/chart1.xml", "word/charts/chart2.xml", "word/embeddings/Microsoft_Excel_Worksheet2.xlsx"} {
+		if !bytes.Equal(entries[name], afterEntries[name]) {
+			t.Errorf("%s is not carried over", name)
+		}
+	}
+
+	if body := string(entries["word/document.xml"]); !strings.Contains(body, `<w:ins w:id="1" w:author="Ann"><w:r><w:drawing>`) {
+		t.Errorf("the added chart is not inserted: %s", body)
+	}
+
+	reread, err := Compare(after, redline.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reread.IsEqual() {
+		t.Errorf("redline differs from the newer document: %#v", reread.Differences)
+	}
+}
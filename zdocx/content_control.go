@@ -0,0 +1,657 @@
+package zdocx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ContentControlPlainText             = "text"
+	ContentControlRichText              = "richText"
+	ContentControlDate                  = "date"
+	ContentControlDropDown              = "dropDownList"
+	ContentControlComboBox              = "comboBox"
+	ContentControlCheckbox              = "checkbox"
+	ContentControlRepeatingSection      = "repeatingSection"
+	ContentControlLockControl           = "sdtLocked"
+	ContentControlLockContent           = "contentLocked"
+	ContentControlLockControlAndContent = "sdtContentLocked"
+	placeholderTextStyleClass           = "PlaceholderText"
+	checkboxFont                        = "MS Gothic"
+	checkboxChecked                     = "\u2612"
+	checkboxUnchecked                   = "\u2610"
+)
+
+// ContentControl is a fillable region of the document (w:sdt). Placed among
+// paragraphs and tables it is a block control, set as Text.ContentControl it
+// is an inline one and its Content may hold only texts.
+type ContentControl struct {
+	Type           string
+	Tag            string
+	Alias          string
+	Placeholder    string
+	Lock           string
+	Value          string
+	Content        []interface{}
+	Date           time.Time
+	DateFormat     string
+	ListItems      []ContentControlListItem
+	IsChecked      bool
+	RepeatingItems [][]interface{}
+	Style          TextStyle
+}
+
+type ContentControlListItem struct {
+	DisplayText string
+	Value       string
+}
+
+func (cc *ContentControl) Error() error {
+	switch cc.Type {
+	case ContentControlPlainText, ContentControlRichText, ContentControlDate, ContentControlDropDown, ContentControlComboBox, ContentControlCheckbox, ContentControlRepeatingSection:
+	default:
+		return errors.New("unknown ContentControl.Type " + cc.Type)
+	}
+
+	switch cc.Lock {
+	case "", ContentControlLockControl, ContentControlLockContent, ContentControlLockControlAndContent:
+	default:
+		return errors.New("unknown ContentControl.Lock " + cc.Lock)
+	}
+
+	for _, item := range cc.ListItems {
+		if item.Value == "" {
+			return errors.New("no ContentControlListItem.Value")
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) SetContentControl(cc *ContentControl) error {
+	var buf bytes.Buffer
+
+	if err := cc.write(&buf, d); err != nil {
+		return errors.Wrap(err, "cc.write")
+	}
+
+	d.body().Write(buf.Bytes())
+
+	return nil
+}
+
+// text returns what the control shows and whether it is the placeholder.
+func (cc *ContentControl) text() (string, bool) {
+	switch cc.Type {
+	case ContentControlCheckbox:
+		if cc.IsChecked {
+			return checkboxChecked, false
+		}
+
+		return checkboxUnchecked, false
+	case ContentControlDate:
+		if !cc.Date.IsZero() {
+			return formatWordDate(cc.Date, cc.dateFormat()), false
+		}
+	case ContentControlDropDown, ContentControlComboBox:
+		for _, item := range cc.ListItems {
+			if item.Value == cc.Value && cc.Value != "" {
+				if item.DisplayText == "" {
+					return item.Value, false
+				}
+
+				return item.DisplayText, false
+			}
+		}
+
+		if cc.Type == ContentControlComboBox && cc.Value != "" {
+			return cc.Value, false
+		}
+	default:
+		if cc.Value != "" {
+			return cc.Value, false
+		}
+	}
+
+	return cc.Placeholder, true
+}
+
+func (cc *ContentControl) dateFormat() string {
+	if cc.DateFormat == "" {
+		return "dd.MM.yyyy"
+	}
+
+	return cc.DateFormat
+}
+
+// texts are the runs written for controls that hold a single value.
+func (cc *ContentControl) texts() ([]*Text, bool) {
+	text, isPlaceholder := cc.text()
+	if text == "" {
+		text = " "
+	}
+
+	t := Text{
+		Text:  text,
+		Style: cc.Style,
+	}
+
+	if isPlaceholder {
+		t.StyleClass = placeholderTextStyleClass
+	}
+
+	if cc.Type == ContentControlCheckbox {
+		t.Style.FontFamily = checkboxFont
+	}
+
+	return []*Text{&t}, isPlaceholder
+}
+
+func (cc *ContentControl) hasContent() bool {
+	return cc.Type == ContentControlRichText && len(cc.Content) > 0
+}
+
+func (d *Document) writeContentControlStart(w xmlWriter, cc *ContentControl, isPlaceholder bool) {
+	w.WriteString(`<w:sdt><w:sdtPr>`)
+
+	if styles := (&Text{Style: cc.Style}).styles(); styles != "" && cc.Type != ContentControlCheckbox {
+		w.WriteString(`<w:rPr>` + styles + `</w:rPr>`)
+	}
+
+	if cc.Alias != "" {
+		w.WriteString(`<w:alias w:val="` + escapeAttr(cc.Alias) + `"/>`)
+	}
+
+	if cc.Tag != "" {
+		w.WriteString(`<w:tag w:val="` + escapeAttr(cc.Tag) + `"/>`)
+	}
+
+	d.contentControlsCount++
+	w.WriteString(`<w:id w:val="` + strconv.Itoa(d.contentControlsCount) + `"/>`)
+
+	if cc.Lock != "" {
+		w.WriteString(`<w:lock w:val="` + cc.Lock + `"/>`)
+	}
+
+	if isPlaceholder {
+		w.WriteString(`<w:showingPlcHdr/>`)
+	}
+
+	switch cc.Type {
+	case ContentControlPlainText:
+		w.WriteString(`<w:text/>`)
+	case ContentControlRichText:
+		w.WriteString(`<w:richText/>`)
+	case ContentControlDate:
+		w.WriteString(`<w:date`)
+
+		if !cc.Date.IsZero() {
+			w.WriteString(` w:fullDate="` + cc.Date.Format("2006-01-02") + `T00:00:00Z"`)
+		}
+
+		w.WriteString(`><w:dateFormat w:val="` + escapeAttr(cc.dateFormat()) + `"/><w:storeMappedDataAs w:val="dateTime"/><w:calendar w:val="gregorian"/></w:date>`)
+	case ContentControlDropDown, ContentControlComboBox:
+		w.WriteString(`<w:` + cc.Type)
+
+		if cc.Value != "" {
+			w.WriteString(` w:lastValue="` + escapeAttr(cc.Value) + `"`)
+		}
+
+		w.WriteString(`>`)
+
+		for _, item := range cc.ListItems {
+			displayText := item.DisplayText
+			if displayText == "" {
+				displayText = item.Value
+			}
+
+			w.WriteString(`<w:listItem w:displayText="` + escapeAttr(displayText) + `" w:value="` + escapeAttr(item.Value) + `"/>`)
+		}
+
+		w.WriteString(`</w:` + cc.Type + `>`)
+	case ContentControlCheckbox:
+		checked := "0"
+		if cc.IsChecked {
+			checked = "1"
+		}
+
+		w.WriteString(`<w14:checkbox><w14:checked w14:val="` + checked + `"/><w14:checkedState w14:val="2612" w14:font="` + checkboxFont + `"/><w14:uncheckedState w14:val="2610" w14:font="` + checkboxFont + `"/></w14:checkbox>`)
+	case ContentControlRepeatingSection:
+		w.WriteString(`<w15:repeatingSection/>`)
+	}
+
+	w.WriteString(`</w:sdtPr><w:sdtContent>`)
+}
+
+func writeContentControlEnd(w xmlWriter) {
+	w.WriteString(`</w:sdtContent></w:sdt>`)
+}
+
+// write writes a block control, single values are put in a paragraph of
+// their own.
+func (cc *ContentControl) write(w xmlWriter, d *Document) error {
+	if err := cc.Error(); err != nil {
+		return err
+	}
+
+	if cc.Type == ContentControlRepeatingSection {
+		if err := cc.writeRepeatingSection(w, d); err != nil {
+			return errors.Wrap(err, "cc.writeRepeatingSection")
+		}
+
+		return nil
+	}
+
+	if cc.hasContent() {
+		d.writeContentControlStart(w, cc, false)
+
+		if err := writeItems(w, d, cc.Content); err != nil {
+			return errors.Wrap(err, "writeItems")
+		}
+
+		writeContentControlEnd(w)
+
+		return nil
+	}
+
+	texts, isPlaceholder := cc.texts()
+
+	d.writeContentControlStart(w, cc, isPlaceholder)
+
+	p := Paragraph{Texts: texts}
+
+	if err := p.write(w, d); err != nil {
+		return errors.Wrap(err, "p.write")
+	}
+
+	writeContentControlEnd(w)
+
+	return nil
+}
+
+func (cc *ContentControl) writeInline(w xmlWriter, d *Document) error {
+	if err := cc.Error(); err != nil {
+		return err
+	}
+
+	if cc.Type == ContentControlRepeatingSection {
+		return errors.New("repeating sections can't be inline")
+	}
+
+	texts, isPlaceholder := cc.texts()
+
+	if cc.hasContent() {
+		texts, isPlaceholder = nil, false
+
+		for _, item := range cc.Content {
+			t, ok := item.(*Text)
+			if !ok {
+				return errors.New("inline ContentControl.Content can hold only texts")
+			}
+
+			texts = append(texts, t)
+		}
+	}
+
+	d.writeContentControlStart(w, cc, isPlaceholder)
+
+	for index, t := range texts {
+		if index != 0 {
+			w.WriteString(d.space())
+		}
+
+		if err := t.write(w, d); err != nil {
+			return errors.Wrap(err, "Text.write")
+		}
+	}
+
+	writeContentControlEnd(w)
+
+	return nil
+}
+
+// writeRepeatingSection wraps each of the repeating items into a control of
+// its own, Word adds and removes items as a whole.
+func (cc *ContentControl) writeRepeatingSection(w xmlWriter, d *Document) error {
+	d.writeContentControlStart(w, cc, false)
+
+	for _, items := range cc.RepeatingItems {
+		d.contentControlsCount++
+		w.WriteString(`<w:sdt><w:sdtPr><w:id w:val="` + strconv.Itoa(d.contentControlsCount) + `"/><w15:repeatingSectionItem/></w:sdtPr><w:sdtContent>`)
+
+		if err := writeItems(w, d, items); err != nil {
+			return errors.Wrap(err, "writeItems")
+		}
+
+		writeContentControlEnd(w)
+	}
+
+	writeContentControlEnd(w)
+
+	return nil
+}
+
+func writeItems(w xmlWriter, d *Document, items []interface{}) error {
+	for _, item := range items {
+		if err := writeContent(w, writeContentArgs{
+			content:  item,
+			document: d,
+		}); err != nil {
+			return errors.Wrap(err, "writeContent")
+		}
+	}
+
+	return nil
+}
+
+// formatWordDate formats date with a Word date format such as "dd.MM.yyyy",
+// text in single quotes is kept as is. Layouts of the time package can not
+// escape text, so every token is formatted on its own and anything else,
+// such as digits or "Jan", is copied as it is.
+func formatWordDate(date time.Time, format string) string {
+	layouts := map[string]string{
+		"d": "2", "dd": "02", "ddd": "Mon", "dddd": "Monday",
+		"M": "1", "MM": "01", "MMM": "Jan", "MMMM": "January",
+		"yy": "06", "yyyy": "2006",
+		"HH": "15", "h": "3", "hh": "03",
+		"m": "4", "mm": "04", "s": "5", "ss": "05",
+	}
+
+	var buf strings.Builder
+
+	for index := 0; index < len(format); {
+		if format[index] == '\'' {
+			end := strings.IndexByte(format[index+1:], '\'')
+			if end < 0 {
+				buf.WriteString(format[index+1:])
+				break
+			}
+
+			buf.WriteString(format[index+1 : index+1+end])
+			index += end + 2
+
+			continue
+		}
+
+		if strings.HasPrefix(strings.ToLower(format[index:]), "am/pm") {
+			if format[index] == 'A' {
+				buf.WriteString(date.Format("PM"))
+			} else {
+				buf.WriteString(date.Format("pm"))
+			}
+
+			index += 5
+
+			continue
+		}
+
+		end := index + 1
+		for end < len(format) && format[end] == format[index] {
+			end++
+		}
+
+		token := format[index:end]
+
+		if token == "H" {
+			buf.WriteString(strconv.Itoa(date.Hour()))
+		} else if layout, ok := layouts[token]; ok {
+			buf.WriteString(date.Format(layout))
+		} else {
+			buf.WriteString(token)
+		}
+
+		index = end
+	}
+
+	return buf.String()
+}
+
+// ContentControlValue is what a content control holds in a filled in
+// document. Value is the item value of dropdowns and "true" or "false" for
+// checkboxes, controls still showing their placeholder have no Text and Value.
+type ContentControlValue struct {
+	Type          string
+	Tag           string
+	Alias         string
+	Text          string
+	Value         string
+	Date          time.Time
+	IsChecked     bool
+	IsPlaceholder bool
+}
+
+type ContentControlValues []ContentControlValue
+
+// Get returns the values of the controls with the tag in document order,
+// controls inside repeating sections give one value per item.
+func (values ContentControlValues) Get(tag string) []ContentControlValue {
+	var found []ContentControlValue
+
+	for _, value := range values {
+		if value.Tag == tag {
+			found = append(found, value)
+		}
+	}
+
+	return found
+}
+
+// Value returns the value of the first control with the tag.
+func (values ContentControlValues) Value(tag string) string {
+	for _, value := range values {
+		if value.Tag == tag {
+			return value.Value
+		}
+	}
+
+	return ""
+}
+
+type contentControlState struct {
+	index     int
+	value     ContentControlValue
+	text      strings.Builder
+	inContent bool
+	isBreak   bool
+	isItem    bool
+	listItems map[string]string
+}
+
+// ReadContentControls reads the content controls of the document body,
+// headers and footers.
+func ReadContentControls(data []byte) (ContentControlValues, error) {
+	pkg, err := openPackage(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "openPackage")
+	}
+
+	var values ContentControlValues
+
+	for _, name := range pkg.names {
+		if name != "word/document.xml" && !strings.HasPrefix(name, "word/header") && !strings.HasPrefix(name, "word/footer") {
+			continue
+		}
+
+		if values, err = readContentControls(pkg.files[name], values); err != nil {
+			return nil, errors.Wrap(err, "readContentControls")
+		}
+	}
+
+	return values, nil
+}
+
+func ReadContentControlsFile(fileName string) (ContentControlValues, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadFile")
+	}
+
+	values, err := ReadContentControls(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "ReadContentControls")
+	}
+
+	return values, nil
+}
+
+func readContentControls(content []byte, values ContentControlValues) (ContentControlValues, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+
+	var (
+		stack  []*contentControlState
+		inText bool
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		var top *contentControlState
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "sdt" {
+				stack = append(stack, &contentControlState{
+					index: len(values),
+					value: ContentControlValue{Type: ContentControlRichText},
+				})
+				values = append(values, ContentControlValue{})
+
+				continue
+			}
+
+			if top == nil {
+				continue
+			}
+
+			if !top.inContent {
+				readContentControlProperty(top, t)
+
+				continue
+			}
+
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				writeContentControlText(stack, "\t")
+			case "br", "cr":
+				writeContentControlText(stack, "\n")
+			case "p":
+				for _, state := range stack {
+					state.isBreak = state.text.Len() > 0
+				}
+			}
+		case xml.CharData:
+			if inText {
+				writeContentControlText(stack, string(t))
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "sdt":
+				if top == nil {
+					continue
+				}
+
+				stack = stack[:len(stack)-1]
+
+				if top.isItem {
+					values = append(values[:top.index], values[top.index+1:]...)
+					continue
+				}
+
+				values[top.index] = top.result()
+			}
+		}
+	}
+}
+
+func readContentControlProperty(state *contentControlState, se xml.StartElement) {
+	switch se.Name.Local {
+	case "sdtContent":
+		state.inContent = true
+	case "alias":
+		state.value.Alias = attr(se, "val")
+	case "tag":
+		state.value.Tag = attr(se, "val")
+	case "showingPlcHdr":
+		state.value.IsPlaceholder = isOn(se)
+	case "text":
+		state.value.Type = ContentControlPlainText
+	case "date":
+		state.value.Type = ContentControlDate
+		state.value.Date, _ = time.Parse(time.RFC3339, attr(se, "fullDate"))
+	case "dropDownList", "comboBox":
+		state.value.Type = se.Name.Local
+		state.listItems = map[string]string{}
+	case "listItem":
+		if state.listItems != nil {
+			state.listItems[attr(se, "displayText")] = attr(se, "value")
+		}
+	case "checkbox":
+		state.value.Type = ContentControlCheckbox
+	case "checked":
+		state.value.IsChecked = isOn(se)
+	case "repeatingSection":
+		state.value.Type = ContentControlRepeatingSection
+	case "repeatingSectionItem":
+		state.isItem = true
+	}
+}
+
+// writeContentControlText adds text to all the controls it is nested in,
+// paragraphs are separated by line breaks, empty ones are left out.
+func writeContentControlText(stack []*contentControlState, text string) {
+	for _, state := range stack {
+		if !state.inContent {
+			continue
+		}
+
+		if state.isBreak {
+			state.text.WriteString("\n")
+			state.isBreak = false
+		}
+
+		state.text.WriteString(text)
+	}
+}
+
+func (state *contentControlState) result() ContentControlValue {
+	value := state.value
+
+	if value.IsPlaceholder {
+		return value
+	}
+
+	value.Text = state.text.String()
+	value.Value = value.Text
+
+	switch value.Type {
+	case ContentControlCheckbox:
+		value.Value = strconv.FormatBool(value.IsChecked)
+	case ContentControlDropDown, ContentControlComboBox:
+		if itemValue, ok := state.listItems[value.Text]; ok {
+			value.Value = itemValue
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+package zdocx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatWordDate(t *testing.T) {
+	date := time.Date(2024, 3, 5, 9, 4, 7, 0, time.UTC)
+
+	for format, expected := range map[string]string{
+		"dd.MM.yyyy":           "05.03.2024",
+		"d MMMM yyyy":          "5 March 2024",
+		"dddd, MMM d, yy":      "Tuesday, Mar 5, 24",
+		"d 'of' MMMM":          "5 of March",
+		"'Q1' yyyy":            "Q1 2024",
+		"Jan 1 yyyy":           "Jan 1 2024",
+		"'Monday' dd/MM 2006":  "Monday 05/03 2006",
+		"H:mm:ss":              "9:04:07",
+		"HH:mm":                "09:04",
+		"h:mm AM/PM":           "9:04 AM",
+		"hh:mm am/pm":          "09:04 am",
+		"yyyy-MM-dd 'MST -07'": "2024-03-05 MST -07",
+		"dd 'unterminated":     "05 unterminated",
+	} {
+		if formatted := formatWordDate(date, format); formatted != expected {
+			t.Errorf("%q: %q, expected %q", format, formatted, expected)
+		}
+	}
+}
+
+func TestReadContentControls(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	doc := NewDocument(NewDocumentArgs{})
+	doc.Header = []*Paragraph{{Texts: []*Text{{ContentControl: &ContentControl{Type: ContentControlPlainText, Tag: "title", Value: "Offer"}}}}}
+
+	for _, cc := range []*ContentControl{
+		{Type: ContentControlPlainText, Tag: "name", Alias: "Name", Value: "Ann & Bob"},
+		{Type: ContentControlPlainText, Tag: "empty", Placeholder: "Type here"},
+		{Type: ContentControlDate, Tag: "date", Date: date, DateFormat: "d 'of' MMMM yyyy"},
+		{Type: ContentControlDropDown, Tag: "size", Value: "m", ListItems: []ContentControlListItem{{DisplayText: "Small", Value: "s"}, {DisplayText: "Medium", Value: "m"}}},
+		{Type: ContentControlCheckbox, Tag: "agree", IsChecked: true},
+	} {
+		if err := doc.SetContentControl(cc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := ReadContentControls(data.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []ContentControlValue{
+		{Type: ContentControlPlainText, Tag: "name", Alias: "Name", Text: "Ann & Bob", Value: "Ann & Bob"},
+		{Type: ContentControlPlainText, Tag: "empty", IsPlaceholder: true},
+		{Type: ContentControlDate, Tag: "date", Text: "5 of March 2024", Value: "5 of March 2024", Date: date},
+		{Type: ContentControlDropDown, Tag: "size", Text: "Medium", Value: "m"},
+		{Type: ContentControlCheckbox, Tag: "agree", Text: checkboxChecked, Value: "true", IsChecked: true},
+		{Type: ContentControlPlainText, Tag: "title", Text: "Offer", Value: "Offer"},
+	} {
+		found := values.Get(expected.Tag)
+
+		if len(found) != 1 {
+			t.Errorf("%s: %d values", expected.Tag, len(found))
+			continue
+		}
+
+		if found[0] != expected {
+			t.Errorf("%s: %+v, expected %+v", expected.Tag, found[0], expected)
+		}
+	}
+}
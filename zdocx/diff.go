@@ -0,0 +1,990 @@
+package zdocx
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DifferenceParagraph = "paragraph"
+	DifferenceRun       = "run"
+	DifferenceImage     = "image"
+	DifferenceChart     = "chart"
+	DifferenceTable     = "table"
+	DifferenceRow       = "row"
+	DifferenceCell      = "cell"
+	DifferenceList      = "list"
+	ChangeAdded         = "added"
+	ChangeRemoved       = "removed"
+	ChangeModified      = "modified"
+	ChangeFormatted     = "formatted"
+	DifferencePart      = "part"
+)
+
+type DiffArgs struct {
+	Old    []interface{}
+	New    []interface{}
+	Author string
+	Date   time.Time
+}
+
+func (args *DiffArgs) error() error {
+	if args.Author == "" {
+		return errors.New("no args.Author")
+	}
+
+	return nil
+}
+
+// Difference is one change found by Diff or Compare. Path points at the item
+// in the new content, or in the old one when the item was removed, for
+// example "body/4/row/1/cell/0/2/run/3".
+type Difference struct {
+	Kind   string
+	Change string
+	Path   string
+	Before string
+	After  string
+}
+
+type differ struct {
+	author      string
+	date        time.Time
+	differences []Difference
+}
+
+// Diff merges two versions of document content into one, marking what was
+// removed from args.Old and added in args.New as tracked changes. Paragraphs
+// replaced one by one are compared run by run, tables row by row and cell by
+// cell. The arguments are not modified.
+func Diff(args DiffArgs) ([]interface{}, error) {
+	items, _, err := diff(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func diff(args DiffArgs) ([]interface{}, []Difference, error) {
+	if err := args.error(); err != nil {
+		return nil, nil, err
+	}
+
+	df := differ{
+		author: args.Author,
+		date:   args.Date,
+	}
+
+	// The merged content reuses what did not change, so it is made from
+	// copies: writing it fills in ids, sizes and styles.
+	copier := contentCopy{comments: map[*Comment]*Comment{}}
+	items := df.items(copier.items(args.Old), copier.items(args.New), "body", "body")
+
+	return items, df.differences, nil
+}
+
+// contentCopy deeply copies document content. A comment that starts and ends
+// in different runs stays one comment in the copy.
+type contentCopy struct {
+	comments map[*Comment]*Comment
+}
+
+func (c *contentCopy) items(items []interface{}) []interface{} {
+	if items == nil {
+		return nil
+	}
+
+	copied := make([]interface{}, len(items))
+
+	for index, item := range items {
+		copied[index] = c.item(item)
+	}
+
+	return copied
+}
+
+func (c *contentCopy) item(item interface{}) interface{} {
+	switch i := item.(type) {
+	case *Paragraph:
+		return c.paragraph(i)
+	case *Table:
+		table := *i
+
+		if i.TR != nil {
+			table.TR = make([]*TR, len(i.TR))
+
+			for index, tr := range i.TR {
+				table.TR[index] = c.row(tr)
+			}
+		}
+
+		return &table
+	case *List:
+		list := *i
+
+		if i.LI != nil {
+			list.LI = make([]*LI, len(i.LI))
+
+			for index, li := range i.LI {
+				list.LI[index] = &LI{Items: c.items(li.Items)}
+			}
+		}
+
+		return &list
+	default:
+		return item
+	}
+}
+
+func (c *contentCopy) row(tr *TR) *TR {
+	row := *tr
+
+	if tr.TD != nil {
+		row.TD = make([]*TD, len(tr.TD))
+
+		for index, td := range tr.TD {
+			cell := *td
+			cell.Content = c.items(td.Content)
+			row.TD[index] = &cell
+		}
+	}
+
+	return &row
+}
+
+func (c *contentCopy) paragraph(p *Paragraph) *Paragraph {
+	paragraph := *p
+
+	if p.Texts != nil {
+		paragraph.Texts = make([]*Text, len(p.Texts))
+
+		for index, t := range p.Texts {
+			paragraph.Texts[index] = c.text(t)
+		}
+	}
+
+	return &paragraph
+}
+
+func (c *contentCopy) text(t *Text) *Text {
+	text := *t
+
+	if t.Link != nil {
+		link := *t.Link
+		text.Link = &link
+	}
+
+	if t.Image != nil {
+		img := *t.Image
+		text.Image = &img
+	}
+
+	if t.Chart != nil {
+		chart := *t.Chart
+		text.Chart = &chart
+	}
+
+	if t.ContentControl != nil {
+		control := *t.ContentControl
+		control.Content = c.items(t.ContentControl.Content)
+		control.RepeatingItems = nil
+
+		for _, items := range t.ContentControl.RepeatingItems {
+			control.RepeatingItems = append(control.RepeatingItems, c.items(items))
+		}
+
+		text.ContentControl = &control
+	}
+
+	text.Footnote = c.items(t.Footnote)
+	text.Endnote = c.items(t.Endnote)
+	text.Comment = c.comment(t.Comment)
+	text.CommentStart = c.comment(t.CommentStart)
+	text.CommentEnd = c.comment(t.CommentEnd)
+
+	return &text
+}
+
+func (c *contentCopy) comment(comment *Comment) *Comment {
+	if comment == nil {
+		return nil
+	}
+
+	if copied, ok := c.comments[comment]; ok {
+		return copied
+	}
+
+	copied := *comment
+	c.comments[comment] = &copied
+	copied.Content = c.items(comment.Content)
+	copied.Replies = nil
+
+	for _, reply := range comment.Replies {
+		copied.Replies = append(copied.Replies, c.comment(reply))
+	}
+
+	return &copied
+}
+
+func (d *Document) SetDiff(args DiffArgs) error {
+	items, err := Diff(args)
+	if err != nil {
+		return errors.Wrap(err, "Diff")
+	}
+
+	if err := d.setItems(items); err != nil {
+		return errors.Wrap(err, "d.setItems")
+	}
+
+	return nil
+}
+
+func (df *differ) revision(revisionType string) *Revision {
+	return &Revision{
+		Type:   revisionType,
+		Author: df.author,
+		Date:   df.date,
+	}
+}
+
+func (df *differ) record(difference Difference) {
+	df.differences = append(df.differences, difference)
+}
+
+func itemPath(prefix string, kind string, index int) string {
+	if kind == "" {
+		return prefix + "/" + strconv.Itoa(index)
+	}
+
+	return prefix + "/" + kind + "/" + strconv.Itoa(index)
+}
+
+func (df *differ) items(before []interface{}, after []interface{}, beforePath string, afterPath string) []interface{} {
+	var (
+		result  []interface{}
+		removed []int
+		added   []int
+	)
+
+	// Removed and added items in between kept ones are aligned once more by
+	// kind, so a dropped paragraph does not turn an edited table into a new one.
+	flush := func() {
+		for _, op := range diffSequence(len(removed), len(added), func(i int, j int) bool {
+			return itemKind(before[removed[i]]) == itemKind(after[added[j]])
+		}) {
+			switch op.kind {
+			case diffKeep:
+				i, j := removed[op.before], added[op.after]
+				result = append(result, df.pair(before[i], after[j], itemPath(beforePath, "", i), itemPath(afterPath, "", j))...)
+			case diffRemove:
+				i := removed[op.before]
+				result = append(result, df.removed(before[i], itemPath(beforePath, "", i)))
+			case diffAdd:
+				j := added[op.after]
+				result = append(result, df.added(after[j], itemPath(afterPath, "", j)))
+			}
+		}
+
+		removed, added = nil, nil
+	}
+
+	beforeSignatures := itemSignatures(before)
+	afterSignatures := itemSignatures(after)
+
+	for _, op := range diffSequence(len(before), len(after), func(i int, j int) bool {
+		return beforeSignatures[i] == afterSignatures[j]
+	}) {
+		switch op.kind {
+		case diffKeep:
+			flush()
+			result = append(result, df.pair(before[op.before], after[op.after], itemPath(beforePath, "", op.before), itemPath(afterPath, "", op.after))...)
+		case diffRemove:
+			removed = append(removed, op.before)
+		case diffAdd:
+			added = append(added, op.after)
+		}
+	}
+
+	flush()
+
+	return result
+}
+
+func (df *differ) removed(item interface{}, path string) interface{} {
+	df.record(Difference{
+		Kind:   itemKind(item),
+		Change: ChangeRemoved,
+		Path:   path,
+		Before: itemText(item),
+	})
+
+	return revisedCopy(item, df.revision(RevisionDelete))
+}
+
+func (df *differ) added(item interface{}, path string) interface{} {
+	df.record(Difference{
+		Kind:   itemKind(item),
+		Change: ChangeAdded,
+		Path:   path,
+		After:  itemText(item),
+	})
+
+	return revisedCopy(item, df.revision(RevisionInsert))
+}
+
+// pair compares two items at the same place, pairs of paragraphs and pairs
+// of tables are merged, anything else is a removal and an insertion.
+func (df *differ) pair(before interface{}, after interface{}, beforePath string, afterPath string) []interface{} {
+	switch b := before.(type) {
+	case *Paragraph:
+		if a, ok := after.(*Paragraph); ok {
+			return []interface{}{df.paragraphs(b, a, beforePath, afterPath)}
+		}
+	case *Table:
+		if a, ok := after.(*Table); ok && len(b.Grid) == len(a.Grid) {
+			return []interface{}{df.tables(b, a, beforePath, afterPath)}
+		}
+	}
+
+	if itemSignature(before) == itemSignature(after) {
+		return []interface{}{after}
+	}
+
+	return []interface{}{df.removed(before, beforePath), df.added(after, afterPath)}
+}
+
+func (df *differ) paragraphs(before *Paragraph, after *Paragraph, beforePath string, afterPath string) *Paragraph {
+	p := *after
+	p.Texts = nil
+
+	if before.StyleClass != after.StyleClass || !reflect.DeepEqual(before.Style, after.Style) {
+		p.StyleRevision = &PStyleRevision{
+			Author:     df.author,
+			Date:       df.date,
+			StyleClass: before.StyleClass,
+			Style:      before.Style,
+		}
+
+		df.record(Difference{
+			Kind:   DifferenceParagraph,
+			Change: ChangeFormatted,
+			Path:   afterPath,
+			Before: itemText(before),
+			After:  itemText(after),
+		})
+	}
+
+	beforeSignatures := textSignatures(before.Texts)
+	afterSignatures := textSignatures(after.Texts)
+
+	for _, op := range diffSequence(len(before.Texts), len(after.Texts), func(i int, j int) bool {
+		return beforeSignatures[i] == afterSignatures[j]
+	}) {
+		switch op.kind {
+		case diffKeep:
+			t := *after.Texts[op.after]
+			previous := before.Texts[op.before]
+
+			if previous.Image != nil && !sameImageExtent(previous.Image, t.Image) {
+				removed := *previous
+				removed.Revision = df.revision(RevisionDelete)
+				t.Revision = df.revision(RevisionInsert)
+				p.Texts = append(p.Texts, &removed, &t)
+
+				df.record(Difference{
+					Kind:   DifferenceImage,
+					Change: ChangeModified,
+					Path:   itemPath(afterPath, "run", op.after),
+					Before: textContent(previous),
+					After:  textContent(&t),
+				})
+
+				continue
+			}
+
+			if previous.StyleClass != t.StyleClass || !reflect.DeepEqual(previous.Style, t.Style) {
+				t.StyleRevision = &TextStyleRevision{
+					Author:     df.author,
+					Date:       df.date,
+					StyleClass: previous.StyleClass,
+					Style:      previous.Style,
+				}
+
+				df.record(Difference{
+					Kind:   textKind(&t),
+					Change: ChangeFormatted,
+					Path:   itemPath(afterPath, "run", op.after),
+					Before: textContent(previous),
+					After:  textContent(&t),
+				})
+			}
+
+			p.Texts = append(p.Texts, &t)
+		case diffRemove:
+			t := *before.Texts[op.before]
+			t.Revision = df.revision(RevisionDelete)
+			p.Texts = append(p.Texts, &t)
+
+			df.record(Difference{
+				Kind:   textKind(&t),
+				Change: ChangeRemoved,
+				Path:   itemPath(beforePath, "run", op.before),
+				Before: textContent(&t),
+			})
+		case diffAdd:
+			t := *after.Texts[op.after]
+			t.Revision = df.revision(RevisionInsert)
+			p.Texts = append(p.Texts, &t)
+
+			df.record(Difference{
+				Kind:   textKind(&t),
+				Change: ChangeAdded,
+				Path:   itemPath(afterPath, "run", op.after),
+				After:  textContent(&t),
+			})
+		}
+	}
+
+	return &p
+}
+
+func (df *differ) tables(before *Table, after *Table, beforePath string, afterPath string) *Table {
+	table := *after
+	table.TR = nil
+
+	var removed, added []int
+
+	// Rows in between kept ones are merged when they have as many cells.
+	flush := func() {
+		for _, op := range diffSequence(len(removed), len(added), func(i int, j int) bool {
+			return len(before.TR[removed[i]].TD) == len(after.TR[added[j]].TD)
+		}) {
+			switch op.kind {
+			case diffKeep:
+				i, j := removed[op.before], added[op.after]
+				table.TR = append(table.TR, df.rows(before.TR[i], after.TR[j], itemPath(beforePath, "row", i), itemPath(afterPath, "row", j)))
+			case diffRemove:
+				i := removed[op.before]
+				table.TR = append(table.TR, revisedRow(before.TR[i], df.revision(RevisionDelete)))
+
+				df.record(Difference{
+					Kind:   DifferenceRow,
+					Change: ChangeRemoved,
+					Path:   itemPath(beforePath, "row", i),
+					Before: rowText(before.TR[i]),
+				})
+			case diffAdd:
+				j := added[op.after]
+				table.TR = append(table.TR, revisedRow(after.TR[j], df.revision(RevisionInsert)))
+
+				df.record(Difference{
+					Kind:   DifferenceRow,
+					Change: ChangeAdded,
+					Path:   itemPath(afterPath, "row", j),
+					After:  rowText(after.TR[j]),
+				})
+			}
+		}
+
+		removed, added = nil, nil
+	}
+
+	for _, op := range diffSequence(len(before.TR), len(after.TR), func(i int, j int) bool {
+		return rowSignature(before.TR[i]) == rowSignature(after.TR[j])
+	}) {
+		switch op.kind {
+		case diffKeep:
+			flush()
+			table.TR = append(table.TR, after.TR[op.after])
+		case diffRemove:
+			removed = append(removed, op.before)
+		case diffAdd:
+			added = append(added, op.after)
+		}
+	}
+
+	flush()
+
+	return &table
+}
+
+// rows merges two rows with the same number of cells, cells that differ get
+// their content compared item by item.
+func (df *differ) rows(before *TR, after *TR, beforePath string, afterPath string) *TR {
+	row := *after
+	row.TD = nil
+
+	for index, td := range after.TD {
+		previous := before.TD[index]
+
+		if cellSignature(previous) == cellSignature(td) {
+			row.TD = append(row.TD, td)
+			continue
+		}
+
+		beforeCellPath := itemPath(beforePath, "cell", index)
+		afterCellPath := itemPath(afterPath, "cell", index)
+
+		df.record(Difference{
+			Kind:   DifferenceCell,
+			Change: ChangeModified,
+			Path:   afterCellPath,
+			Before: cellText(previous),
+			After:  cellText(td),
+		})
+
+		cell := *td
+		cell.Content = df.items(previous.Content, td.Content, beforeCellPath, afterCellPath)
+		row.TD = append(row.TD, &cell)
+	}
+
+	return &row
+}
+
+func revisedCopy(item interface{}, r *Revision) interface{} {
+	switch i := item.(type) {
+	case *Paragraph:
+		p := *i
+		p.Revision = r
+		p.Texts = nil
+
+		for _, t := range i.Texts {
+			text := *t
+			text.Revision = r
+			p.Texts = append(p.Texts, &text)
+		}
+
+		return &p
+	case *Table:
+		table := *i
+		table.TR = nil
+
+		for _, tr := range i.TR {
+			table.TR = append(table.TR, revisedRow(tr, r))
+		}
+
+		return &table
+	case *List:
+		list := *i
+		list.LI = nil
+
+		for _, li := range i.LI {
+			copied := &LI{}
+
+			for _, item := range li.Items {
+				copied.Items = append(copied.Items, revisedCopy(item, r))
+			}
+
+			list.LI = append(list.LI, copied)
+		}
+
+		return &list
+	default:
+		return item
+	}
+}
+
+func revisedRow(tr *TR, r *Revision) *TR {
+	row := *tr
+	row.Revision = r
+	row.TD = nil
+
+	for _, td := range tr.TD {
+		cell := *td
+		cell.Content = nil
+
+		for _, item := range td.Content {
+			cell.Content = append(cell.Content, revisedCopy(item, r))
+		}
+
+		row.TD = append(row.TD, &cell)
+	}
+
+	return &row
+}
+
+func itemKind(item interface{}) string {
+	switch i := item.(type) {
+	case *Paragraph:
+		if len(i.Texts) == 1 && i.Texts[0].Image != nil && i.Texts[0].Text == "" {
+			return DifferenceImage
+		}
+
+		return DifferenceParagraph
+	case *Table:
+		return DifferenceTable
+	case *List:
+		return DifferenceList
+	default:
+		return ""
+	}
+}
+
+func textKind(t *Text) string {
+	if t.Image != nil {
+		return DifferenceImage
+	}
+
+	if t.Chart != nil {
+		return DifferenceChart
+	}
+
+	return DifferenceRun
+}
+
+// sameImageExtent compares the sizes asked for two images with the same
+// bytes, a resized image is shown as removed and added again.
+func sameImageExtent(a *Image, b *Image) bool {
+	if a.WidthPercent != b.WidthPercent {
+		return false
+	}
+
+	return imageEMU(a, a.Width) == imageEMU(b, b.Width) && imageEMU(a, a.Height) == imageEMU(b, b.Height)
+}
+
+func imageEMU(img *Image, value int64) int64 {
+	dpi := float64(img.DPI)
+	if dpi == 0 {
+		dpi = defaultDPI
+	}
+
+	return sizeToEMU(value, img.SizeUnit, dpi)
+}
+
+func textContent(t *Text) string {
+	if t.Image != nil && t.Text == "" {
+		return t.Image.FileName
+	}
+
+	if t.Chart != nil && t.Text == "" {
+		return t.Chart.Name
+	}
+
+	return t.Text
+}
+
+func itemText(item interface{}) string {
+	var texts []string
+
+	switch i := item.(type) {
+	case *Paragraph:
+		for _, t := range i.Texts {
+			texts = append(texts, textContent(t))
+		}
+
+		return strings.Join(texts, " ")
+	case *Table:
+		for _, tr := range i.TR {
+			texts = append(texts, rowText(tr))
+		}
+
+		return strings.Join(texts, "\n")
+	case *List:
+		for _, li := range i.LI {
+			for _, item := range li.Items {
+				texts = append(texts, itemText(item))
+			}
+		}
+
+		return strings.Join(texts, "\n")
+	default:
+		return ""
+	}
+}
+
+func rowText(tr *TR) string {
+	var texts []string
+
+	for _, td := range tr.TD {
+		texts = append(texts, cellText(td))
+	}
+
+	return strings.Join(texts, "\t")
+}
+
+func cellText(td *TD) string {
+	var texts []string
+
+	for _, item := range td.Content {
+		texts = append(texts, itemText(item))
+	}
+
+	return strings.Join(texts, " ")
+}
+
+func textSignature(t *Text) string {
+	switch {
+	case t.Image != nil:
+		sum := sha1.Sum(t.Image.Bytes)
+
+		return t.Text + "\x00" + hex.EncodeToString(sum[:])
+	case t.Chart != nil:
+		sum := sha1.Sum(t.Chart.chartPart())
+
+		return t.Text + "\x00chart:" + hex.EncodeToString(sum[:])
+	default:
+		return t.Text
+	}
+}
+
+func textSignatures(texts []*Text) []string {
+	signatures := make([]string, len(texts))
+
+	for index, t := range texts {
+		signatures[index] = textSignature(t)
+	}
+
+	return signatures
+}
+
+func itemSignatures(items []interface{}) []string {
+	signatures := make([]string, len(items))
+
+	for index, item := range items {
+		signatures[index] = itemSignature(item)
+	}
+
+	return signatures
+}
+
+func itemSignature(item interface{}) string {
+	var buf strings.Builder
+
+	switch i := item.(type) {
+	case *Paragraph:
+		buf.WriteString("p:")
+
+		for _, t := range i.Texts {
+			buf.WriteString(textSignature(t) + "\x01")
+		}
+	case *Table:
+		buf.WriteString("table:")
+
+		for _, tr := range i.TR {
+			buf.WriteString(rowSignature(tr) + "\x02")
+		}
+	case *List:
+		buf.WriteString("list:")
+
+		for _, li := range i.LI {
+			for _, item := range li.Items {
+				buf.WriteString(itemSignature(item) + "\x02")
+			}
+		}
+	}
+
+	return buf.String()
+}
+
+func rowSignature(tr *TR) string {
+	var buf strings.Builder
+
+	for _, td := range tr.TD {
+		buf.WriteString(cellSignature(td) + "\x04")
+	}
+
+	return buf.String()
+}
+
+func cellSignature(td *TD) string {
+	var buf strings.Builder
+
+	for _, item := range td.Content {
+		buf.WriteString(itemSignature(item) + "\x03")
+	}
+
+	return buf.String()
+}
+
+const (
+	diffKeep = iota
+	diffRemove
+	diffAdd
+)
+
+type diffOperation struct {
+	kind   int
+	before int
+	after  int
+}
+
+// diffSequence returns the shortest edit script of two sequences, removals
+// come before additions at the same place. It runs Myers' O(ND) algorithm on
+// the part left after the common prefix and suffix, splitting at the middle
+// snake so memory stays linear in the length of the sequences.
+func diffSequence(beforeLen int, afterLen int, equal func(i int, j int) bool) []diffOperation {
+	sequence := sequenceDiff{equal: equal}
+	sequence.compare(0, beforeLen, 0, afterLen)
+
+	var (
+		operations []diffOperation
+		added      []diffOperation
+	)
+
+	for _, op := range sequence.operations {
+		switch op.kind {
+		case diffAdd:
+			added = append(added, op)
+		case diffRemove:
+			operations = append(operations, op)
+		default:
+			operations = append(operations, added...)
+			operations = append(operations, op)
+			added = nil
+		}
+	}
+
+	return append(operations, added...)
+}
+
+type sequenceDiff struct {
+	equal      func(i int, j int) bool
+	operations []diffOperation
+}
+
+func (s *sequenceDiff) keep(before int, after int, count int) {
+	for index := 0; index < count; index++ {
+		s.operations = append(s.operations, diffOperation{kind: diffKeep, before: before + index, after: after + index})
+	}
+}
+
+func (s *sequenceDiff) replace(beforeStart int, beforeEnd int, afterStart int, afterEnd int) {
+	for i := beforeStart; i < beforeEnd; i++ {
+		s.operations = append(s.operations, diffOperation{kind: diffRemove, before: i})
+	}
+
+	for j := afterStart; j < afterEnd; j++ {
+		s.operations = append(s.operations, diffOperation{kind: diffAdd, after: j})
+	}
+}
+
+func (s *sequenceDiff) compare(beforeStart int, beforeEnd int, afterStart int, afterEnd int) {
+	prefix := 0
+	for beforeStart+prefix < beforeEnd && afterStart+prefix < afterEnd && s.equal(beforeStart+prefix, afterStart+prefix) {
+		prefix++
+	}
+
+	s.keep(beforeStart, afterStart, prefix)
+	beforeStart += prefix
+	afterStart += prefix
+
+	suffix := 0
+	for beforeEnd-suffix > beforeStart && afterEnd-suffix > afterStart && s.equal(beforeEnd-suffix-1, afterEnd-suffix-1) {
+		suffix++
+	}
+
+	beforeEnd -= suffix
+	afterEnd -= suffix
+
+	if beforeStart == beforeEnd || afterStart == afterEnd {
+		s.replace(beforeStart, beforeEnd, afterStart, afterEnd)
+	} else {
+		s.bisect(beforeStart, beforeEnd, afterStart, afterEnd)
+	}
+
+	s.keep(beforeEnd, afterEnd, suffix)
+}
+
+// bisect walks the edit graph from both ends at once until the paths meet,
+// then compares the two halves around the meeting point on their own. The
+// backward path keeps its x distances from the end of the sequences.
+func (s *sequenceDiff) bisect(beforeStart int, beforeEnd int, afterStart int, afterEnd int) {
+	n, m := beforeEnd-beforeStart, afterEnd-afterStart
+	maxD := (n + m + 1) / 2
+	offset := maxD
+
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+
+	for index := range forward {
+		forward[index] = -1
+		backward[index] = -1
+	}
+
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	delta := n - m
+	odd := delta%2 != 0
+
+	var forwardStart, forwardEnd, backwardStart, backwardEnd int
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && s.equal(beforeStart+x, afterStart+y) {
+				x++
+				y++
+			}
+
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd:
+				back := offset + delta - k
+				if back >= 0 && back < len(backward) && backward[back] != -1 && x >= n-backward[back] {
+					s.split(beforeStart, beforeEnd, afterStart, afterEnd, x, y)
+					return
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && s.equal(beforeEnd-x-1, afterEnd-y-1) {
+				x++
+				y++
+			}
+
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd:
+				front := offset + delta - k
+				if front >= 0 && front < len(forward) && forward[front] != -1 {
+					forwardX := forward[front]
+					if forwardX >= n-x {
+						s.split(beforeStart, beforeEnd, afterStart, afterEnd, forwardX, forwardX-(delta-k))
+						return
+					}
+				}
+			}
+		}
+	}
+
+	s.replace(beforeStart, beforeEnd, afterStart, afterEnd)
+}
+
+func (s *sequenceDiff) split(beforeStart int, beforeEnd int, afterStart int, afterEnd int, x int, y int) {
+	s.compare(beforeStart, beforeStart+x, afterStart, afterStart+y)
+	s.compare(beforeStart+x, beforeEnd, afterStart+y, afterEnd)
+}
@@ -0,0 +1,162 @@
+package zdocx
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func lcsLength(before []byte, after []byte) int {
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			switch {
+			case before[i] == after[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+// checkScript replays the operations and returns the number of kept items.
+func checkScript(t *testing.T, before []byte, after []byte, operations []diffOperation) int {
+	t.Helper()
+
+	var i, j, kept int
+	added := false
+
+	for _, op := range operations {
+		switch op.kind {
+		case diffKeep:
+			if op.before != i || op.after != j || before[i] != after[j] {
+				t.Fatalf("%q %q: bad keep %+v", before, after, op)
+			}
+
+			i++
+			j++
+			kept++
+			added = false
+		case diffRemove:
+			if op.before != i || added {
+				t.Fatalf("%q %q: bad removal %+v", before, after, op)
+			}
+
+			i++
+		case diffAdd:
+			if op.after != j {
+				t.Fatalf("%q %q: bad addition %+v", before, after, op)
+			}
+
+			j++
+			added = true
+		}
+	}
+
+	if i != len(before) || j != len(after) {
+		t.Fatalf("%q %q: script stops at %d, %d", before, after, i, j)
+	}
+
+	return kept
+}
+
+func randomSequence(random *rand.Rand, length int) []byte {
+	sequence := make([]byte, length)
+
+	for index := range sequence {
+		sequence[index] = byte('a' + random.Intn(4))
+	}
+
+	return sequence
+}
+
+func TestDiffSequence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 2000; round++ {
+		before := randomSequence(random, random.Intn(20))
+		after := randomSequence(random, random.Intn(20))
+
+		operations := diffSequence(len(before), len(after), func(i int, j int) bool {
+			return before[i] == after[j]
+		})
+
+		if kept, expected := checkScript(t, before, after, operations), lcsLength(before, after); kept != expected {
+			t.Fatalf("%q %q: %d items kept, the longest common subsequence is %d", before, after, kept, expected)
+		}
+	}
+}
+
+func TestDiffSequenceLarge(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	before := randomSequence(random, 200000)
+	after := append([]byte{}, before...)
+
+	for edit := 0; edit < 50; edit++ {
+		after[random.Intn(len(after))] = 'z'
+	}
+
+	after = append(after[:1000], after[1010:]...)
+
+	operations := diffSequence(len(before), len(after), func(i int, j int) bool {
+		return before[i] == after[j]
+	})
+
+	if kept := checkScript(t, before, after, operations); kept < len(after)-50 {
+		t.Errorf("only %d of %d items kept", kept, len(after))
+	}
+}
+
+func diffContent(png []byte) []interface{} {
+	comment := &Comment{Author: "Ann", Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "note"}}}}}
+
+	return []interface{}{
+		&Paragraph{Texts: []*Text{{Text: "kept", CommentStart: comment}, {Text: " text", CommentEnd: comment}}},
+		&Paragraph{Texts: []*Text{{Image: &Image{Bytes: png, FileName: "dot.png"}}}},
+		&Table{
+			Grid:  []int{3000, 3000},
+			Style: TableStyle{Borders: Borders{Top: Border{Width: 4, Color: "000000", Type: "single"}}},
+			TR: []*TR{
+				{TD: []*TD{
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "a"}}}}},
+					{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "b"}}}}},
+				}},
+			},
+		},
+	}
+}
+
+func TestDiffDoesNotModifyArguments(t *testing.T) {
+	png := testPNG(t, 2, 2)
+	old := diffContent(png)
+	new := diffContent(png)
+	new = append(new, &Paragraph{Texts: []*Text{{Text: "added"}}})
+
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetDiff(DiffArgs{Old: old, New: new, Author: "Bob", Date: time.Unix(0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := doc.WriteToBuffer(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(old, diffContent(png)) {
+		t.Error("Diff modified args.Old")
+	}
+
+	if !reflect.DeepEqual(new[:3], diffContent(png)) {
+		t.Error("Diff modified args.New")
+	}
+}
@@ -0,0 +1,577 @@
+package zdocx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"hash"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	encryptionSpinCount     = 100000
+	encryptionMaxSpinCount  = 10000000
+	encryptionMaxSaltSize   = 65536
+	encryptionSegment       = 4096
+	passwordKeyEncryptorURI = "http://schemas.microsoft.com/office/2006/keyEncryptor/password"
+)
+
+var ErrWrongPassword = errors.New("wrong password")
+
+// Block keys of the agile encryption, MS-OFFCRYPTO 2.3.4.11 to 2.3.4.14.
+var (
+	verifierHashInputBlockKey = []byte{0xfe, 0xa7, 0xd2, 0x76, 0x3b, 0x4b, 0x9e, 0x79}
+	verifierHashValueBlockKey = []byte{0xd7, 0xaa, 0x0f, 0x6d, 0x30, 0x61, 0x34, 0x4e}
+	encryptedKeyValueBlockKey = []byte{0x14, 0x6e, 0x0b, 0xe7, 0xab, 0xac, 0xd0, 0xd6}
+	hmacKeyBlockKey           = []byte{0x5f, 0xb2, 0xad, 0x01, 0x0c, 0xb9, 0xe1, 0xf6}
+	hmacValueBlockKey         = []byte{0xa0, 0x67, 0x7f, 0x02, 0xb2, 0x2c, 0x84, 0x33}
+)
+
+var encryptionHashes = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA384": sha512.New384,
+	"SHA512": sha512.New,
+}
+
+type encryptionKeyData struct {
+	SaltSize        int    `xml:"saltSize,attr"`
+	BlockSize       int    `xml:"blockSize,attr"`
+	KeyBits         int    `xml:"keyBits,attr"`
+	HashSize        int    `xml:"hashSize,attr"`
+	CipherAlgorithm string `xml:"cipherAlgorithm,attr"`
+	CipherChaining  string `xml:"cipherChaining,attr"`
+	HashAlgorithm   string `xml:"hashAlgorithm,attr"`
+	SaltValue       string `xml:"saltValue,attr"`
+}
+
+type encryptionPasswordKey struct {
+	encryptionKeyData
+	SpinCount                  int    `xml:"spinCount,attr"`
+	EncryptedVerifierHashInput string `xml:"encryptedVerifierHashInput,attr"`
+	EncryptedVerifierHashValue string `xml:"encryptedVerifierHashValue,attr"`
+	EncryptedKeyValue          string `xml:"encryptedKeyValue,attr"`
+}
+
+type encryptionDescriptor struct {
+	KeyData       encryptionKeyData `xml:"keyData"`
+	DataIntegrity struct {
+		EncryptedHmacKey   string `xml:"encryptedHmacKey,attr"`
+		EncryptedHmacValue string `xml:"encryptedHmacValue,attr"`
+	} `xml:"dataIntegrity"`
+	KeyEncryptors []struct {
+		URI          string                `xml:"uri,attr"`
+		EncryptedKey encryptionPasswordKey `xml:"encryptedKey"`
+	} `xml:"keyEncryptors>keyEncryptor"`
+}
+
+func (k *encryptionKeyData) attributes(salt []byte) string {
+	return ` saltSize="` + strconv.Itoa(k.SaltSize) + `" blockSize="` + strconv.Itoa(k.BlockSize) + `" keyBits="` + strconv.Itoa(k.KeyBits) + `" hashSize="` + strconv.Itoa(k.HashSize) + `" cipherAlgorithm="` + k.CipherAlgorithm + `" cipherChaining="` + k.CipherChaining + `" hashAlgorithm="` + k.HashAlgorithm + `" saltValue="` + base64.StdEncoding.EncodeToString(salt) + `"`
+}
+
+func (k *encryptionKeyData) error() error {
+	if k.CipherAlgorithm != "AES" || k.CipherChaining != "ChainingModeCBC" {
+		return errors.New("unsupported cipher " + k.CipherAlgorithm + " " + k.CipherChaining)
+	}
+
+	switch k.KeyBits {
+	case 128, 192, 256:
+	default:
+		return errors.New("unsupported key size " + strconv.Itoa(k.KeyBits))
+	}
+
+	if k.BlockSize != aes.BlockSize {
+		return errors.New("unsupported block size " + strconv.Itoa(k.BlockSize))
+	}
+
+	newHash, ok := encryptionHashes[k.HashAlgorithm]
+	if !ok {
+		return errors.New("unsupported hash algorithm " + k.HashAlgorithm)
+	}
+
+	// The sizes are read from the file and cut the decrypted values.
+	if k.HashSize != newHash().Size() {
+		return errors.New("unsupported hash size " + strconv.Itoa(k.HashSize))
+	}
+
+	if k.SaltSize < 1 || k.SaltSize > encryptionMaxSaltSize {
+		return errors.New("unsupported salt size " + strconv.Itoa(k.SaltSize))
+	}
+
+	return nil
+}
+
+// The spin count is read from the file, it is capped so a crafted one does
+// not keep Decrypt hashing for hours.
+func (k *encryptionPasswordKey) error() error {
+	if err := k.encryptionKeyData.error(); err != nil {
+		return err
+	}
+
+	if k.SpinCount < 0 || k.SpinCount > encryptionMaxSpinCount {
+		return errors.New("unsupported spin count " + strconv.Itoa(k.SpinCount))
+	}
+
+	return nil
+}
+
+func (k *encryptionKeyData) hash(parts ...[]byte) []byte {
+	h := encryptionHashes[k.HashAlgorithm]()
+
+	for _, part := range parts {
+		h.Write(part)
+	}
+
+	return h.Sum(nil)
+}
+
+// fit cuts b to size or pads it with 0x36, keys and initialization vectors
+// derived from hashes are adjusted this way.
+func fit(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b[:size]
+	}
+
+	return append(append([]byte{}, b...), bytes.Repeat([]byte{0x36}, size-len(b))...)
+}
+
+// passwordKeys derive the keys that encrypt the verifier and the package key
+// from the password: the salted password is hashed and rehashed spinCount
+// times after the iteration number, then once more with each block key.
+func (k *encryptionPasswordKey) passwordKeys(password string, blockKeys ...[]byte) ([][]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(k.SaltValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "base64 saltValue")
+	}
+
+	h := k.hash(salt, utf16LE(password))
+	iterator := make([]byte, 4)
+
+	for i := 0; i < k.SpinCount; i++ {
+		binary.LittleEndian.PutUint32(iterator, uint32(i))
+		h = k.hash(iterator, h)
+	}
+
+	keys := make([][]byte, len(blockKeys))
+
+	for index, blockKey := range blockKeys {
+		keys[index] = fit(k.hash(h, blockKey), k.KeyBits/8)
+	}
+
+	return keys, nil
+}
+
+func aesCBC(key []byte, iv []byte, data []byte, encrypt bool) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "aes.NewCipher")
+	}
+
+	out := make([]byte, len(data)+(aes.BlockSize-len(data)%aes.BlockSize)%aes.BlockSize)
+	copy(out, data)
+
+	if encrypt {
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	} else {
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, out)
+	}
+
+	return out, nil
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "rand.Read")
+	}
+
+	return b, nil
+}
+
+// Encrypt wraps a .docx package into the agile encryption container of
+// ECMA-376 with AES-256 and SHA-512, Word asks for the password on open.
+func Encrypt(data []byte, password string) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("no password")
+	}
+
+	keyData := encryptionKeyData{
+		SaltSize:        16,
+		BlockSize:       aes.BlockSize,
+		KeyBits:         256,
+		HashSize:        sha512.Size,
+		CipherAlgorithm: "AES",
+		CipherChaining:  "ChainingModeCBC",
+		HashAlgorithm:   "SHA512",
+	}
+
+	var random [5][]byte
+
+	for index, size := range []int{keyData.SaltSize, keyData.SaltSize, keyData.KeyBits / 8, keyData.SaltSize, keyData.HashSize} {
+		b, err := randomBytes(size)
+		if err != nil {
+			return nil, errors.Wrap(err, "randomBytes")
+		}
+
+		random[index] = b
+	}
+
+	keyDataSalt, passwordSalt, secretKey, verifierHashInput, hmacKey := random[0], random[1], random[2], random[3], random[4]
+
+	passwordKey := encryptionPasswordKey{
+		encryptionKeyData: keyData,
+		SpinCount:         encryptionSpinCount,
+	}
+	passwordKey.SaltValue = base64.StdEncoding.EncodeToString(passwordSalt)
+
+	encryptedPackage, err := encryptPackage(data, &keyData, keyDataSalt, secretKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "encryptPackage")
+	}
+
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(encryptedPackage)
+
+	passwordKeys, err := passwordKey.passwordKeys(password, verifierHashInputBlockKey, verifierHashValueBlockKey, encryptedKeyValueBlockKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "passwordKey.passwordKeys")
+	}
+
+	encrypted := map[string][]byte{}
+
+	for _, i := range []struct {
+		name  string
+		key   []byte
+		iv    []byte
+		value []byte
+	}{
+		{"encryptedVerifierHashInput", passwordKeys[0], passwordSalt, verifierHashInput},
+		{"encryptedVerifierHashValue", passwordKeys[1], passwordSalt, keyData.hash(verifierHashInput)},
+		{"encryptedKeyValue", passwordKeys[2], passwordSalt, secretKey},
+		{"encryptedHmacKey", secretKey, fit(keyData.hash(keyDataSalt, hmacKeyBlockKey), keyData.BlockSize), hmacKey},
+		{"encryptedHmacValue", secretKey, fit(keyData.hash(keyDataSalt, hmacValueBlockKey), keyData.BlockSize), mac.Sum(nil)},
+	} {
+		if encrypted[i.name], err = aesCBC(i.key, i.iv, i.value, true); err != nil {
+			return nil, errors.Wrap(err, "aesCBC")
+		}
+	}
+
+	encode := func(name string) string {
+		return base64.StdEncoding.EncodeToString(encrypted[name])
+	}
+
+	var info bytes.Buffer
+	info.Write([]byte{0x04, 0x00, 0x04, 0x00, 0x40, 0x00, 0x00, 0x00})
+	info.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\r\n")
+	info.WriteString(`<encryption xmlns="http://schemas.microsoft.com/office/2006/encryption" xmlns:p="http://schemas.microsoft.com/office/2006/keyEncryptor/password" xmlns:c="http://schemas.microsoft.com/office/2006/keyEncryptor/certificate">`)
+	info.WriteString(`<keyData` + keyData.attributes(keyDataSalt) + `/>`)
+	info.WriteString(`<dataIntegrity encryptedHmacKey="` + encode("encryptedHmacKey") + `" encryptedHmacValue="` + encode("encryptedHmacValue") + `"/>`)
+	info.WriteString(`<keyEncryptors><keyEncryptor uri="` + passwordKeyEncryptorURI + `">`)
+	info.WriteString(`<p:encryptedKey spinCount="` + strconv.Itoa(passwordKey.SpinCount) + `"` + keyData.attributes(passwordSalt))
+	info.WriteString(` encryptedVerifierHashInput="` + encode("encryptedVerifierHashInput") + `" encryptedVerifierHashValue="` + encode("encryptedVerifierHashValue") + `" encryptedKeyValue="` + encode("encryptedKeyValue") + `"/>`)
+	info.WriteString(`</keyEncryptor></keyEncryptors></encryption>`)
+
+	root := &compoundEntry{name: "Root Entry"}
+	root.add("EncryptionInfo", info.Bytes())
+	root.add("EncryptedPackage", encryptedPackage)
+
+	for name, content := range dataSpaces() {
+		root.add(name, content)
+	}
+
+	out, err := writeCompoundFile(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "writeCompoundFile")
+	}
+
+	return out, nil
+}
+
+// encryptPackage encrypts the package in segments of 4096 bytes, each with
+// its own initialization vector, after the size of the package.
+func encryptPackage(data []byte, keyData *encryptionKeyData, salt []byte, secretKey []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(data)))
+	buf.Write(size)
+
+	if err := cryptSegments(&buf, data, keyData, salt, secretKey, true); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func cryptSegments(buf *bytes.Buffer, data []byte, keyData *encryptionKeyData, salt []byte, secretKey []byte, encrypt bool) error {
+	index := make([]byte, 4)
+
+	for segment := 0; segment*encryptionSegment < len(data); segment++ {
+		end := (segment + 1) * encryptionSegment
+		if end > len(data) {
+			end = len(data)
+		}
+
+		binary.LittleEndian.PutUint32(index, uint32(segment))
+
+		out, err := aesCBC(secretKey, fit(keyData.hash(salt, index), keyData.BlockSize), data[segment*encryptionSegment:end], encrypt)
+		if err != nil {
+			return errors.Wrap(err, "aesCBC")
+		}
+
+		buf.Write(out)
+	}
+
+	return nil
+}
+
+// dataSpaces returns the streams that tell readers the package is wrapped
+// by the strong encryption transform, MS-OFFCRYPTO 2.1.
+func dataSpaces() map[string][]byte {
+	lengthPrefixed := func(b *bytes.Buffer, s string) {
+		units := utf16LE(s)
+		binary.Write(b, binary.LittleEndian, uint32(len(units)))
+		b.Write(units)
+		b.Write(make([]byte, (4-len(units)%4)%4))
+	}
+
+	versions := func(b *bytes.Buffer) {
+		for index := 0; index < 3; index++ {
+			binary.Write(b, binary.LittleEndian, []uint16{1, 0})
+		}
+	}
+
+	var version bytes.Buffer
+	lengthPrefixed(&version, "Microsoft.Container.DataSpaces")
+	versions(&version)
+
+	var entry bytes.Buffer
+	binary.Write(&entry, binary.LittleEndian, uint32(1))
+	binary.Write(&entry, binary.LittleEndian, uint32(0))
+	lengthPrefixed(&entry, "EncryptedPackage")
+	lengthPrefixed(&entry, "StrongEncryptionDataSpace")
+
+	var dataSpaceMap bytes.Buffer
+	binary.Write(&dataSpaceMap, binary.LittleEndian, []uint32{8, 1, uint32(entry.Len() + 4)})
+	dataSpaceMap.Write(entry.Bytes())
+
+	var definition bytes.Buffer
+	binary.Write(&definition, binary.LittleEndian, []uint32{8, 1})
+	lengthPrefixed(&definition, "StrongEncryptionTransform")
+
+	var transformID bytes.Buffer
+	lengthPrefixed(&transformID, "{FF9A3F03-56EF-4613-BDD5-5A41C1D07246}")
+
+	var transform bytes.Buffer
+	binary.Write(&transform, binary.LittleEndian, []uint32{uint32(transformID.Len() + 8), 1})
+	transform.Write(transformID.Bytes())
+	lengthPrefixed(&transform, "Microsoft.Container.EncryptionTransform")
+	versions(&transform)
+	binary.Write(&transform, binary.LittleEndian, []uint32{0, 0, 0, 4})
+
+	return map[string][]byte{
+		"\x06DataSpaces/Version":                                             version.Bytes(),
+		"\x06DataSpaces/DataSpaceMap":                                        dataSpaceMap.Bytes(),
+		"\x06DataSpaces/DataSpaceInfo/StrongEncryptionDataSpace":             definition.Bytes(),
+		"\x06DataSpaces/TransformInfo/StrongEncryptionTransform/\x06Primary": transform.Bytes(),
+	}
+}
+
+func IsEncrypted(data []byte) bool {
+	return isCompoundFile(data)
+}
+
+// Decrypt returns the .docx package of a document encrypted with agile
+// encryption, ErrWrongPassword is returned when the password does not match.
+func Decrypt(data []byte, password string) ([]byte, error) {
+	streams, err := readCompoundFile(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "readCompoundFile")
+	}
+
+	info, ok := streams["EncryptionInfo"]
+	if !ok {
+		return nil, errors.New("no EncryptionInfo")
+	}
+
+	encryptedPackage, ok := streams["EncryptedPackage"]
+	if !ok || len(encryptedPackage) < 8 {
+		return nil, errors.New("no EncryptedPackage")
+	}
+
+	if len(info) < 8 || binary.LittleEndian.Uint16(info) != 4 || binary.LittleEndian.Uint16(info[2:]) != 4 {
+		return nil, errors.New("unsupported encryption, only agile encryption can be decrypted")
+	}
+
+	var descriptor encryptionDescriptor
+
+	if err := xml.Unmarshal(info[8:], &descriptor); err != nil {
+		return nil, errors.Wrap(err, "xml.Unmarshal")
+	}
+
+	keyData := &descriptor.KeyData
+	if err := keyData.error(); err != nil {
+		return nil, err
+	}
+
+	var passwordKey *encryptionPasswordKey
+
+	for index := range descriptor.KeyEncryptors {
+		if descriptor.KeyEncryptors[index].URI == passwordKeyEncryptorURI {
+			passwordKey = &descriptor.KeyEncryptors[index].EncryptedKey
+		}
+	}
+
+	if passwordKey == nil {
+		return nil, errors.New("no password key encryptor")
+	}
+
+	if err := passwordKey.error(); err != nil {
+		return nil, err
+	}
+
+	secretKey, err := passwordKey.secretKey(password)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(keyData.SaltValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "base64 saltValue")
+	}
+
+	if err := checkIntegrity(&descriptor, secretKey, salt, encryptedPackage); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint64(encryptedPackage)
+	encrypted := encryptedPackage[8:]
+
+	if len(encrypted)%keyData.BlockSize != 0 || uint64(len(encrypted)) < size {
+		return nil, errors.New("broken EncryptedPackage")
+	}
+
+	var buf bytes.Buffer
+
+	if err := cryptSegments(&buf, encrypted, keyData, salt, secretKey, false); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes()[:size], nil
+}
+
+func DecryptFile(fileName string, password string) ([]byte, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadFile")
+	}
+
+	decrypted, err := Decrypt(data, password)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decrypt")
+	}
+
+	return decrypted, nil
+}
+
+// secretKey checks the password against the verifier and decrypts the key
+// the package is encrypted with.
+func (k *encryptionPasswordKey) secretKey(password string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(k.SaltValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "base64 saltValue")
+	}
+
+	keys, err := k.passwordKeys(password, verifierHashInputBlockKey, verifierHashValueBlockKey, encryptedKeyValueBlockKey)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string][]byte{}
+
+	for index, i := range []struct {
+		name  string
+		value string
+	}{
+		{"verifierHashInput", k.EncryptedVerifierHashInput},
+		{"verifierHashValue", k.EncryptedVerifierHashValue},
+		{"keyValue", k.EncryptedKeyValue},
+	} {
+		encrypted, err := base64.StdEncoding.DecodeString(i.value)
+		if err != nil {
+			return nil, errors.Wrap(err, "base64 "+i.name)
+		}
+
+		if values[i.name], err = aesCBC(keys[index], fit(salt, k.BlockSize), encrypted, false); err != nil {
+			return nil, errors.Wrap(err, "aesCBC")
+		}
+	}
+
+	if len(values["verifierHashInput"]) < k.SaltSize || len(values["verifierHashValue"]) < k.HashSize || len(values["keyValue"]) < k.KeyBits/8 {
+		return nil, errors.New("broken password key encryptor")
+	}
+
+	verifierHash := k.hash(values["verifierHashInput"][:k.SaltSize])
+
+	if subtle.ConstantTimeCompare(verifierHash, values["verifierHashValue"][:k.HashSize]) != 1 {
+		return nil, ErrWrongPassword
+	}
+
+	return values["keyValue"][:k.KeyBits/8], nil
+}
+
+// checkIntegrity compares the HMAC of the encrypted package with the one
+// stored next to it.
+func checkIntegrity(descriptor *encryptionDescriptor, secretKey []byte, salt []byte, encryptedPackage []byte) error {
+	keyData := &descriptor.KeyData
+
+	if descriptor.DataIntegrity.EncryptedHmacKey == "" {
+		return nil
+	}
+
+	values := map[string][]byte{}
+
+	for _, i := range []struct {
+		name     string
+		value    string
+		blockKey []byte
+	}{
+		{"key", descriptor.DataIntegrity.EncryptedHmacKey, hmacKeyBlockKey},
+		{"value", descriptor.DataIntegrity.EncryptedHmacValue, hmacValueBlockKey},
+	} {
+		encrypted, err := base64.StdEncoding.DecodeString(i.value)
+		if err != nil {
+			return errors.Wrap(err, "base64 hmac "+i.name)
+		}
+
+		if values[i.name], err = aesCBC(secretKey, fit(keyData.hash(salt, i.blockKey), keyData.BlockSize), encrypted, false); err != nil {
+			return errors.Wrap(err, "aesCBC")
+		}
+
+		if len(values[i.name]) < keyData.HashSize {
+			return errors.New("broken data integrity")
+		}
+	}
+
+	mac := hmac.New(encryptionHashes[keyData.HashAlgorithm], values["key"][:keyData.HashSize])
+	mac.Write(encryptedPackage)
+
+	if !hmac.Equal(mac.Sum(nil), values["value"][:keyData.HashSize]) {
+		return errors.New("EncryptedPackage integrity check failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package zdocx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+
+	return data
+}
+
+// The sizes cover the 4096 byte segments and packages kept in the mini
+// stream, in regular sectors and in more FAT sectors than the header lists.
+func TestEncryptDecrypt(t *testing.T) {
+	sizes := []int{1, 15, 16, 4087, 4088, 4095, 4096, 4097, 8191, 8192, 8193, 300000}
+
+	if !testing.Short() {
+		sizes = append(sizes, 8<<20)
+	}
+
+	for _, size := range sizes {
+		data := randomData(size)
+
+		encrypted, err := Encrypt(data, "secret")
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+
+		if !IsEncrypted(encrypted) {
+			t.Fatalf("%d: not encrypted", size)
+		}
+
+		streams, err := readCompoundFile(encrypted)
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+
+		if length := len(streams["EncryptedPackage"]); length != 8+(size+15)/16*16 {
+			t.Errorf("%d: EncryptedPackage of %d bytes", size, length)
+		}
+
+		decrypted, err := Decrypt(encrypted, "secret")
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("%d: decrypted data differs", size)
+		}
+	}
+
+	if _, err := Encrypt([]byte("data"), ""); err == nil {
+		t.Error("encrypted without a password")
+	}
+}
+
+func encryptedStreams(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	encrypted, err := Encrypt(randomData(10000), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streams, err := readCompoundFile(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return streams
+}
+
+func compoundFile(t *testing.T, streams map[string][]byte) []byte {
+	t.Helper()
+
+	root := &compoundEntry{name: "Root Entry"}
+
+	for name, data := range streams {
+		root.add(name, data)
+	}
+
+	data, err := writeCompoundFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestDecryptWrongPassword(t *testing.T) {
+	if _, err := Decrypt(compoundFile(t, encryptedStreams(t)), "Secret"); err != ErrWrongPassword {
+		t.Errorf("wrong password: %v", err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	streams := encryptedStreams(t)
+	streams["EncryptedPackage"][5000] ^= 1
+
+	if _, err := Decrypt(compoundFile(t, streams), "secret"); err == nil || err == ErrWrongPassword {
+		t.Errorf("changed package: %v", err)
+	}
+
+	streams = encryptedStreams(t)
+	info := string(streams["EncryptionInfo"])
+	start := strings.Index(info, `encryptedHmacValue="`) + len(`encryptedHmacValue="`)
+	replacement := "A"
+
+	if info[start] == 'A' {
+		replacement = "B"
+	}
+
+	streams["EncryptionInfo"] = []byte(info[:start] + replacement + info[start+1:])
+
+	if _, err := Decrypt(compoundFile(t, streams), "secret"); err == nil || err == ErrWrongPassword {
+		t.Errorf("changed HMAC: %v", err)
+	}
+}
+
+func TestDecryptSpinCountLimit(t *testing.T) {
+	streams := encryptedStreams(t)
+	streams["EncryptionInfo"] = bytes.Replace(streams["EncryptionInfo"], []byte(`spinCount="100000"`), []byte(`spinCount="2000000000"`), 1)
+
+	if _, err := Decrypt(compoundFile(t, streams), "secret"); err == nil || !strings.Contains(err.Error(), "spin count") {
+		t.Errorf("huge spin count: %v", err)
+	}
+}
+
+func TestDecryptKeySizes(t *testing.T) {
+	for _, attribute := range []string{`hashSize="-1"`, `hashSize="20"`, `saltSize="-1"`, `saltSize="0"`, `saltSize="70000"`} {
+		name := attribute[:strings.Index(attribute, "=")]
+		streams := encryptedStreams(t)
+		info := string(streams["EncryptionInfo"])
+
+		// Both the key data and the password key have the attribute.
+		for _, start := range []int{strings.Index(info, name+`="`), strings.LastIndex(info, name+`="`)} {
+			end := start + strings.Index(info[start:], `" `) + 1
+			streams["EncryptionInfo"] = []byte(info[:start] + attribute + info[end:])
+
+			if _, err := Decrypt(compoundFile(t, streams), "secret"); err == nil || !strings.Contains(err.Error(), "size") {
+				t.Errorf("%s at %d: %v", attribute, start, err)
+			}
+		}
+	}
+}
+
+func TestDecryptExcelFile(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/excel_agile_sha1.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Decrypt(data, "passwd"); err != ErrWrongPassword {
+		t.Errorf("wrong password: %v", err)
+	}
+
+	decrypted, err := Decrypt(data, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := zipEntries(t, decrypted)["xl/workbook.xml"]; !ok {
+		t.Error("no xl/workbook.xml in the decrypted package")
+	}
+}
+
+func TestWriteToBufferPassword(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "secret text"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := doc.WriteToBuffer(WriteToBufferArgs{Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := Decrypt(buf.Bytes(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(zipEntries(t, decrypted)["word/document.xml"], []byte("secret text")) {
+		t.Error("no text in the decrypted document")
+	}
+}
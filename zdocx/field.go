@@ -0,0 +1,342 @@
+package zdocx
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FieldTypePage          = "PAGE"
+	FieldTypeNumPages      = "NUMPAGES"
+	FieldTypeSectionPages  = "SECTIONPAGES"
+	FieldFormatArabic      = "Arabic"
+	FieldFormatArabicDash  = "ArabicDash"
+	FieldFormatLowerRoman  = "roman"
+	FieldFormatUpperRoman  = "ROMAN"
+	FieldFormatLowerLetter = "alphabetic"
+	FieldFormatUpperLetter = "ALPHABETIC"
+	FieldFormatMerge       = "MERGEFORMAT"
+	FieldTypeDate          = "DATE"
+	FieldTypeTime          = "TIME"
+	FieldTypeCreateDate    = "CREATEDATE"
+	FieldTypeAuthor        = "AUTHOR"
+	FieldTypeTitle         = "TITLE"
+	FieldTypeFileName      = "FILENAME"
+	FieldTypeDocProperty   = "DOCPROPERTY"
+	FieldTypeSequence      = "SEQ"
+	FieldTypePageReference = "PAGEREF"
+	FieldTypeFormText      = "FORMTEXT"
+	FieldTypeFormCheckbox  = "FORMCHECKBOX"
+	FieldTypeFormDropDown  = "FORMDROPDOWN"
+)
+
+type Field struct {
+	Type        string
+	Argument    string
+	Format      string
+	Switches    []string
+	Instruction string
+	Result      string
+	IsDirty     bool
+	IsSimple    bool
+	Form        *FormField
+}
+
+var formFieldNamePrefixes = map[string]string{
+	FieldTypeFormText:     "Text",
+	FieldTypeFormCheckbox: "Check",
+	FieldTypeFormDropDown: "Dropdown",
+}
+
+func DateFormatSwitch(picture string) string {
+	return `\@ ` + fieldArgument(picture)
+}
+
+func NumberFormatSwitch(picture string) string {
+	return `\# ` + fieldArgument(picture)
+}
+
+func TextFormatSwitch(format string) string {
+	return `\* ` + format
+}
+
+func (f *Field) instruction() string {
+	if f.Instruction != "" {
+		return " " + strings.TrimSpace(f.Instruction) + " "
+	}
+
+	parts := []string{f.Type}
+
+	if f.Argument != "" {
+		parts = append(parts, fieldArgument(f.Argument))
+	}
+
+	if f.Format != "" {
+		parts = append(parts, TextFormatSwitch(f.Format))
+	}
+
+	parts = append(parts, f.Switches...)
+
+	return " " + strings.Join(parts, " ") + " "
+}
+
+func (f *Field) isPageNumber() bool {
+	switch f.Type {
+	case FieldTypePage, FieldTypeNumPages, FieldTypeSectionPages:
+		return f.Instruction == ""
+	default:
+		return false
+	}
+}
+
+func (f *Field) result() string {
+	if f.Result != "" || !f.isPageNumber() {
+		return f.Result
+	}
+
+	switch f.Format {
+	case FieldFormatLowerRoman:
+		return "i"
+	case FieldFormatUpperRoman:
+		return "I"
+	case FieldFormatLowerLetter:
+		return "a"
+	case FieldFormatUpperLetter:
+		return "A"
+	case FieldFormatArabicDash:
+		return "- 1 -"
+	default:
+		return "1"
+	}
+}
+
+// Fields without a precomputed result are marked dirty so Word fills them
+// in, page numbers are always recalculated on layout. Document.UpdateFields
+// also asks Word to update all fields every time the document is opened.
+func (f *Field) isDirty() bool {
+	return f.IsDirty || (f.Result == "" && !f.isPageNumber())
+}
+
+func (f *Field) write(w xmlWriter, d *Document, properties string) {
+	if f.isForm() {
+		f.writeForm(w, d, properties)
+
+		return
+	}
+
+	dirty := ""
+
+	if f.isDirty() {
+		dirty = ` w:dirty="true"`
+	}
+
+	if f.IsSimple {
+		w.WriteString(`<w:fldSimple w:instr="` + escapeAttr(f.instruction()) + `"` + dirty + `>`)
+		f.writeResult(w, d, properties)
+		w.WriteString(`</w:fldSimple>`)
+
+		return
+	}
+
+	w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="begin"` + dirty + `/></w:r>`)
+	w.WriteString(`<w:r>` + properties + `<w:` + d.instrTextTag() + ` xml:space="preserve">`)
+	xml.EscapeText(w, []byte(f.instruction()))
+	w.WriteString(`</w:` + d.instrTextTag() + `></w:r>`)
+	w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="separate"/></w:r>`)
+	f.writeResult(w, d, properties)
+	w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="end"/></w:r>`)
+}
+
+func (f *Field) writeResult(w xmlWriter, d *Document, properties string) {
+	result := f.result()
+	if result == "" {
+		return
+	}
+
+	w.WriteString(`<w:r>` + properties + `<w:` + d.textTag() + ` xml:space="preserve">`)
+	xml.EscapeText(w, []byte(result))
+	w.WriteString(`</w:` + d.textTag() + `></w:r>`)
+}
+
+func fieldArgument(value string) string {
+	if strings.ContainsAny(value, " \"\\") {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+
+	return value
+}
+
+func pagination() *Paragraph {
+	return &Paragraph{
+		Style: PStyle{
+			HorisontalAlign: HorisontalAlignRight,
+			Margins: Margins{
+				Top:    &Margin{Value: 0},
+				Bottom: &Margin{Value: 0},
+			},
+		},
+		Texts: []*Text{
+			{
+				Field: &Field{
+					Type: FieldTypePage,
+				},
+			},
+		},
+	}
+}
+
+// FormField holds the data of a legacy form field, Value falls back to
+// Default. Entries are the choices of a dropdown, Default and Value name one
+// of them.
+type FormField struct {
+	Name               string
+	Default            string
+	Value              string
+	MaxLength          int
+	Entries            []string
+	IsChecked          bool
+	IsCheckedByDefault bool
+	HelpText           string
+}
+
+func (f *Field) isForm() bool {
+	switch f.Type {
+	case FieldTypeFormText, FieldTypeFormCheckbox, FieldTypeFormDropDown:
+		return f.Instruction == ""
+	default:
+		return false
+	}
+}
+
+func (f *Field) Error() error {
+	if !f.isForm() {
+		return nil
+	}
+
+	if f.Form == nil {
+		return nil
+	}
+
+	if len([]rune(f.Form.Name)) > 20 {
+		return errors.New("FormField.Name is longer than 20 characters")
+	}
+
+	switch f.Type {
+	case FieldTypeFormText:
+		if f.Form.MaxLength > 0 && len([]rune(f.Form.value())) > f.Form.MaxLength {
+			return errors.New("FormField value is longer than FormField.MaxLength")
+		}
+	case FieldTypeFormDropDown:
+		if len(f.Form.Entries) > 25 {
+			return errors.New("more than 25 FormField.Entries")
+		}
+
+		for _, value := range []string{f.Form.Default, f.Form.Value} {
+			if value != "" && f.Form.entryIndex(value) < 0 {
+				return errors.New("no FormField.Entries item " + value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (form *FormField) value() string {
+	if form.Value != "" {
+		return form.Value
+	}
+
+	return form.Default
+}
+
+func (form *FormField) entryIndex(entry string) int {
+	for index, i := range form.Entries {
+		if i == entry {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// writeForm writes a legacy form field inside a bookmark named after it,
+// only text fields have a result, checkboxes and dropdowns are drawn by Word
+// from their data.
+func (f *Field) writeForm(w xmlWriter, d *Document, properties string) {
+	form := f.Form
+	if form == nil {
+		form = &FormField{}
+	}
+
+	d.bookmarksCount++
+	bookmarkID := strconv.Itoa(d.bookmarksCount)
+
+	name := form.Name
+	if name == "" {
+		name = formFieldNamePrefixes[f.Type] + bookmarkID
+	}
+
+	w.WriteString(`<w:bookmarkStart w:id="` + bookmarkID + `" w:name="` + escapeAttr(name) + `"/>`)
+	w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="begin"><w:ffData>`)
+	w.WriteString(`<w:name w:val="` + escapeAttr(name) + `"/><w:enabled/><w:calcOnExit w:val="0"/>`)
+
+	if form.HelpText != "" {
+		w.WriteString(`<w:helpText w:type="text" w:val="` + escapeAttr(form.HelpText) + `"/>`)
+	}
+
+	switch f.Type {
+	case FieldTypeFormText:
+		w.WriteString(`<w:textInput>`)
+
+		if form.Default != "" {
+			w.WriteString(`<w:default w:val="` + escapeAttr(form.Default) + `"/>`)
+		}
+
+		if form.MaxLength > 0 {
+			w.WriteString(`<w:maxLength w:val="` + strconv.Itoa(form.MaxLength) + `"/>`)
+		}
+
+		w.WriteString(`</w:textInput>`)
+	case FieldTypeFormCheckbox:
+		w.WriteString(`<w:checkBox><w:sizeAuto/><w:default w:val="` + strconv.FormatBool(form.IsCheckedByDefault) + `"/><w:checked w:val="` + strconv.FormatBool(form.IsChecked) + `"/></w:checkBox>`)
+	case FieldTypeFormDropDown:
+		w.WriteString(`<w:ddList>`)
+
+		if form.Value != "" {
+			w.WriteString(`<w:result w:val="` + strconv.Itoa(form.entryIndex(form.Value)) + `"/>`)
+		}
+
+		if form.Default != "" {
+			w.WriteString(`<w:default w:val="` + strconv.Itoa(form.entryIndex(form.Default)) + `"/>`)
+		}
+
+		for _, entry := range form.Entries {
+			w.WriteString(`<w:listEntry w:val="` + escapeAttr(entry) + `"/>`)
+		}
+
+		w.WriteString(`</w:ddList>`)
+	}
+
+	w.WriteString(`</w:ffData></w:fldChar></w:r>`)
+	w.WriteString(`<w:r>` + properties + `<w:` + d.instrTextTag() + ` xml:space="preserve">`)
+	xml.EscapeText(w, []byte(f.instruction()))
+	w.WriteString(`</w:` + d.instrTextTag() + `></w:r>`)
+
+	if f.Type == FieldTypeFormText {
+		result := form.value()
+		if result == "" {
+			result = strings.Repeat("\u2002", 5)
+		}
+
+		w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="separate"/></w:r>`)
+		w.WriteString(`<w:r>` + properties + `<w:` + d.textTag() + ` xml:space="preserve">`)
+		xml.EscapeText(w, []byte(result))
+		w.WriteString(`</w:` + d.textTag() + `></w:r>`)
+	}
+
+	w.WriteString(`<w:r>` + properties + `<w:fldChar w:fldCharType="end"/></w:r>`)
+	w.WriteString(`<w:bookmarkEnd w:id="` + bookmarkID + `"/>`)
+}
@@ -0,0 +1,80 @@
+package zdocx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFieldWrite(t *testing.T) {
+	for _, test := range []struct {
+		field    Field
+		expected []string
+		dirty    bool
+	}{
+		{
+			field:    Field{Type: FieldTypePage, Format: FieldFormatUpperRoman},
+			expected: []string{`<w:instrText xml:space="preserve"> PAGE \* ROMAN </w:instrText>`, `<w:t xml:space="preserve">I</w:t>`},
+		},
+		{
+			field:    Field{Type: FieldTypeDocProperty, Argument: "Company Name"},
+			expected: []string{`<w:instrText xml:space="preserve"> DOCPROPERTY &#34;Company Name&#34; </w:instrText>`},
+			dirty:    true,
+		},
+		{
+			field:    Field{Type: FieldTypeDate, Switches: []string{DateFormatSwitch("d MMMM yyyy")}, Result: "1 May 2024"},
+			expected: []string{`DATE \@ &#34;d MMMM yyyy&#34;`, `<w:t xml:space="preserve">1 May 2024</w:t>`},
+		},
+		{
+			field:    Field{Type: FieldTypeAuthor, IsSimple: true, Result: "Ann"},
+			expected: []string{`<w:fldSimple w:instr=" AUTHOR ">`},
+		},
+		{
+			field:    Field{Instruction: `IF 1 = 1 "a < b"`, IsDirty: true, Result: "a < b"},
+			expected: []string{`> IF 1 = 1 &#34;a &lt; b&#34; </w:instrText>`, `a &lt; b</w:t>`},
+			dirty:    true,
+		},
+	} {
+		var buf bytes.Buffer
+
+		test.field.write(&buf, NewDocument(NewDocumentArgs{}), "")
+
+		for _, expected := range test.expected {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("%s: no %s", buf.String(), expected)
+			}
+		}
+
+		if dirty := strings.Contains(buf.String(), `w:dirty="true"`); dirty != test.dirty {
+			t.Errorf("%s: dirty %v, expected %v", buf.String(), dirty, test.dirty)
+		}
+	}
+}
+
+func TestUpdateFieldsIsOptIn(t *testing.T) {
+	for _, update := range []bool{false, true} {
+		doc := NewDocument(NewDocumentArgs{})
+		doc.UpdateFields = update
+
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Field: &Field{Type: FieldTypeTitle}}}}); err != nil {
+			t.Fatal(err)
+		}
+
+		doc.SetListOfFigures()
+
+		data, err := doc.WriteToBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries := zipEntries(t, data.Bytes())
+
+		if !bytes.Contains(entries["word/document.xml"], []byte(`w:dirty="true"`)) {
+			t.Error("the field without a result is not dirty")
+		}
+
+		if written := bytes.Contains(entries["word/settings.xml"], []byte(`<w:updateFields w:val="true"/>`)); written != update {
+			t.Errorf("UpdateFields %v, updateFields written: %v", update, written)
+		}
+	}
+}
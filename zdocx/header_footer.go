@@ -0,0 +1,311 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerTag                   = "hdr"
+	footerTag                   = "ftr"
+	headerReferenceDefault      = "default"
+	headerReferenceFirst        = "first"
+	headerReferenceEven         = "even"
+	headerFooterID              = "fileHeaderFooterID"
+	PageNumberFormatDecimal     = "decimal"
+	PageNumberFormatUpperRoman  = "upperRoman"
+	PageNumberFormatLowerRoman  = "lowerRoman"
+	PageNumberFormatUpperLetter = "upperLetter"
+	PageNumberFormatLowerLetter = "lowerLetter"
+)
+
+type headerFooterPart struct {
+	partRelations
+	tag        string
+	reference  string
+	content    []*Paragraph
+	relsID     string
+	width      int
+	pageNumber bool
+}
+
+type headerFooterSlot struct {
+	tag       string
+	reference string
+	content   []*Paragraph
+}
+
+func (part *headerFooterPart) key() string {
+	return part.tag + ":" + part.reference
+}
+
+func (part *headerFooterPart) isPaginated() bool {
+	return part.pageNumber && part.tag == footerTag && part.reference == headerReferenceDefault && part.content != nil
+}
+
+func (part *headerFooterPart) baseName() string {
+	if part.tag == headerTag {
+		return "header"
+	}
+
+	return "footer"
+}
+
+func (d *Document) headerOrFooterCount(tag string) int {
+	var count int
+
+	for _, part := range d.headersAndFooters {
+		if part.tag == tag {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (part *headerFooterPart) contentType() string {
+	if part.tag == headerTag {
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"
+	}
+
+	return "application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"
+}
+
+func (part *headerFooterPart) relationshipType() string {
+	if part.tag == headerTag {
+		return "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header"
+	}
+
+	return "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer"
+}
+
+func (part *headerFooterPart) elementName() string {
+	if part.tag == headerTag {
+		return "headerReference"
+	}
+
+	return "footerReference"
+}
+
+type writeHeaderFooterReferencesArgs struct {
+	slots          []headerFooterSlot
+	linkToPrevious bool
+	pageNumber     bool
+	width          int
+}
+
+// A section without a reference of some type inherits it from the previous
+// section, so when a section is not linked to the previous one an empty part
+// is referenced instead of a missing one that was set earlier.
+func (d *Document) writeHeaderFooterReferences(w xmlWriter, args writeHeaderFooterReferencesArgs) bool {
+	if d.activeHeadersAndFooters == nil {
+		d.activeHeadersAndFooters = map[string]bool{}
+	}
+
+	for _, slot := range args.slots {
+		part := &headerFooterPart{
+			tag:        slot.tag,
+			reference:  slot.reference,
+			content:    slot.content,
+			width:      args.width,
+			pageNumber: args.pageNumber,
+		}
+
+		if slot.content == nil && (args.linkToPrevious || !d.activeHeadersAndFooters[part.key()]) {
+			continue
+		}
+
+		d.headersAndFooters = append(d.headersAndFooters, part)
+		part.fileName = part.baseName() + strconv.Itoa(d.headerOrFooterCount(part.tag))
+		part.relsID = headerFooterID + strconv.Itoa(len(d.headersAndFooters))
+		d.activeHeadersAndFooters[part.key()] = slot.content != nil
+
+		if slot.reference == headerReferenceEven && slot.content != nil {
+			d.hasEvenHeaders = true
+		}
+
+		w.WriteString(`<w:` + part.elementName() + ` w:type="` + part.reference + `" r:id="` + part.relsID + `"/>`)
+	}
+
+	return d.activeHeadersAndFooters[headerTag+":"+headerReferenceFirst] || d.activeHeadersAndFooters[footerTag+":"+headerReferenceFirst]
+}
+
+func pageNumberType(format string, start int) string {
+	if format == "" {
+		format = PageNumberFormatDecimal
+	}
+
+	if start > 0 {
+		return `<w:pgNumType w:fmt="` + format + `" w:start="` + strconv.Itoa(start) + `"/>`
+	}
+
+	return `<w:pgNumType w:fmt="` + format + `"/>`
+}
+
+type writeHeaderAndFooterFilesArgs struct {
+	document *Document
+	writer   *zip.Writer
+}
+
+func writeHeaderAndFooterFiles(args writeHeaderAndFooterFilesArgs) error {
+	for _, part := range args.document.headersAndFooters {
+		if err := writeHeaderOrFooter(writeHeaderOrFooterArgs{
+			document: args.document,
+			part:     part,
+			writer:   args.writer,
+		}); err != nil {
+			return errors.Wrap(err, "writeHeaderOrFooter")
+		}
+	}
+
+	return nil
+}
+
+type writeHeaderOrFooterArgs struct {
+	document *Document
+	part     *headerFooterPart
+	writer   *zip.Writer
+}
+
+func (args *writeHeaderOrFooterArgs) Error() error {
+	if args.part == nil {
+		return errors.New("no args.part")
+	}
+
+	if args.document == nil {
+		return errors.New("no args.document")
+	}
+
+	return nil
+}
+
+func writeHeaderOrFooter(args writeHeaderOrFooterArgs) error {
+	if err := args.Error(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	args.document.currentPart = &args.part.partRelations
+	defer func() {
+		args.document.currentPart = nil
+	}()
+
+	buf.WriteString(getDocumentStartTags(args.part.tag))
+
+	content := []interface{}{}
+
+	for _, p := range args.part.content {
+		p.Style.Margins = Margins{
+			Top:    &Margin{Value: 0},
+			Bottom: &Margin{Value: 0},
+		}
+
+		if args.part.isPaginated() {
+			content = append(content, p)
+		} else {
+			if err := p.write(&buf, args.document); err != nil {
+				return errors.Wrap(err, "p.write")
+			}
+		}
+	}
+
+	if args.part.isPaginated() {
+		style := TDStyle{
+			Margins: Margins{
+				Top:    &Margin{Value: 0},
+				Left:   &Margin{Value: 0},
+				Bottom: &Margin{Value: 0},
+				Right:  &Margin{Value: 0},
+			},
+		}
+
+		table := Table{
+			Type: "fixed",
+			Grid: []int{
+				int(float32(args.part.width) * 0.5),
+				int(float32(args.part.width) * 0.5),
+			},
+			TR: []*TR{
+				{
+					TD: []*TD{
+						{
+							Style:   style,
+							Content: content,
+						},
+						{
+							Style: style,
+							Content: []interface{}{
+								pagination(),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		if err := table.write(&buf, args.document); err != nil {
+			return errors.Wrap(err, "table.write")
+		}
+	}
+
+	if len(args.part.content) == 0 {
+		buf.WriteString("<w:p/>")
+	}
+
+	buf.WriteString("</w:" + args.part.tag + ">")
+
+	contentFile, err := args.writer.Create("word/" + args.part.fileName + ".xml")
+	if err != nil {
+		return errors.Wrap(err, "writer.Create")
+	}
+
+	_, err = contentFile.Write(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "contentFile.Write")
+	}
+
+	if err := writePartRelsFile(writePartRelsFileArgs{
+		part:   &args.part.partRelations,
+		writer: args.writer,
+	}); err != nil {
+		return errors.Wrap(err, "writePartRelsFile")
+	}
+
+	return nil
+}
+
+type writePartRelsFileArgs struct {
+	part   *partRelations
+	writer *zip.Writer
+}
+
+func writePartRelsFile(args writePartRelsFileArgs) error {
+	if len(args.part.images) == 0 && len(args.part.links) == 0 {
+		return nil
+	}
+
+	file, err := args.writer.Create("word/_rels/" + args.part.fileName + ".xml.rels")
+	if err != nil {
+		return errors.Wrap(err, "writer.Create")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	buf.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	buf.WriteString(imageRelationships(args.part.images))
+	buf.WriteString(linkRelationships(args.part.links))
+
+	buf.WriteString(`</Relationships>`)
+
+	_, err = file.Write(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "file.Write")
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package zdocx
 
 import (
+	"encoding/base64"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -10,11 +14,16 @@ import (
 type Node struct {
 	Tag      string
 	Text     string
+	Attrs    map[string]string
+	Bytes    []byte
 	Children []*Node
 }
 
+type LoadImageFunc func(src string) ([]byte, error)
+
 type ParseHTMLArgs struct {
-	Text string
+	Text      string
+	LoadImage LoadImageFunc
 }
 
 func ParseHTML(args ParseHTMLArgs) (*Node, error) {
@@ -27,6 +36,7 @@ func ParseHTML(args ParseHTMLArgs) (*Node, error) {
 
 	root := Node{}
 
+	var loadErr error
 	var f func(*html.Node, *Node)
 
 	f = func(n *html.Node, parent *Node) {
@@ -37,6 +47,18 @@ func ParseHTML(args ParseHTMLArgs) (*Node, error) {
 				Tag: n.Data,
 			}
 
+			for _, attr := range n.Attr {
+				if theParent.Attrs == nil {
+					theParent.Attrs = map[string]string{}
+				}
+
+				theParent.Attrs[attr.Key] = attr.Val
+			}
+
+			if n.Data == "img" && loadErr == nil {
+				theParent.Bytes, loadErr = loadHTMLImage(theParent.Attrs["src"], args.LoadImage)
+			}
+
 			parent.Children = append(parent.Children, theParent)
 		} else if n.Type == html.TextNode {
 			trimedText := strings.TrimSpace(n.Data)
@@ -71,9 +93,56 @@ func ParseHTML(args ParseHTMLArgs) (*Node, error) {
 
 	f(doc, &root)
 
+	if loadErr != nil {
+		return nil, errors.Wrap(loadErr, "loadHTMLImage")
+	}
+
 	return &root, nil
 }
 
+func loadHTMLImage(src string, load LoadImageFunc) ([]byte, error) {
+	if src == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(src, "data:") {
+		comma := strings.Index(src, ",")
+		if comma == -1 {
+			return nil, errors.New("invalid data url")
+		}
+
+		meta := src[len("data:"):comma]
+		data := src[comma+1:]
+
+		if strings.HasSuffix(meta, ";base64") {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, errors.Wrap(err, "base64.DecodeString")
+			}
+
+			return decoded, nil
+		}
+
+		decoded, err := url.PathUnescape(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "url.PathUnescape")
+		}
+
+		return []byte(decoded), nil
+	}
+
+	if load == nil {
+		return nil, nil
+	}
+
+	data, err := load(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "load")
+	}
+
+	return data, nil
+}
+
 func (d *Document) HTMLToXML(node *Node) error {
 	if err := d.setTagsFromNode(node); err != nil {
 		return errors.Wrap(err, "d.setTagsFromNode")
@@ -83,7 +152,8 @@ func (d *Document) HTMLToXML(node *Node) error {
 }
 
 type ItemsFromHTMLArgs struct {
-	Text string
+	Text      string
+	LoadImage LoadImageFunc
 }
 
 func ItemsFromHTML(args ItemsFromHTMLArgs) ([]interface{}, error) {
@@ -92,7 +162,8 @@ func ItemsFromHTML(args ItemsFromHTMLArgs) ([]interface{}, error) {
 	}
 
 	node, err := ParseHTML(ParseHTMLArgs{
-		Text: args.Text,
+		Text:      args.Text,
+		LoadImage: args.LoadImage,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "ParseHTML")
@@ -107,7 +178,7 @@ func ItemsFromHTML(args ItemsFromHTMLArgs) ([]interface{}, error) {
 }
 
 func HTMLToXMLItems(node *Node, items []interface{}) ([]interface{}, error) {
-	if node.Tag == "p" {
+	if node.Tag == "p" || node.Tag == "img" {
 		item, err := node.xmlStruct()
 		if err != nil {
 			return nil, errors.Wrap(err, "node.xmlStruct")
@@ -152,7 +223,7 @@ func HTMLToXMLItems(node *Node, items []interface{}) ([]interface{}, error) {
 }
 
 func (d *Document) setTagsFromNode(node *Node) error {
-	if node.Tag == "p" {
+	if node.Tag == "p" || node.Tag == "img" {
 		item, err := node.xmlStruct()
 		if err != nil {
 			return errors.Wrap(err, "node.xmlStruct")
@@ -245,6 +316,16 @@ func (n *Node) xmlPStruct() (*Paragraph, error) {
 	p := &Paragraph{}
 
 	for _, i := range n.Children {
+		if i.Tag == "img" {
+			if img := i.xmlImageStruct(); img != nil {
+				p.Texts = append(p.Texts, &Text{
+					Image: img,
+				})
+			}
+
+			continue
+		}
+
 		p.Texts = append(p.Texts, &Text{
 			Text: i.Text,
 			Style: TextStyle{
@@ -257,6 +338,81 @@ func (n *Node) xmlPStruct() (*Paragraph, error) {
 	return p, nil
 }
 
+func (n *Node) xmlImageStruct() *Image {
+	if len(n.Bytes) == 0 {
+		return nil
+	}
+
+	src := n.Attrs["src"]
+	fileName := "image"
+
+	if !strings.HasPrefix(src, "data:") {
+		fileName = path.Base(src)
+	}
+
+	alt, hasAlt := n.Attrs["alt"]
+
+	img := Image{
+		FileName:     fileName,
+		Bytes:        n.Bytes,
+		Description:  alt,
+		Title:        n.Attrs["title"],
+		IsDecorative: (hasAlt && strings.TrimSpace(alt) == "") || n.Attrs["role"] == "presentation",
+		Width:        htmlPixels(n.Attrs["width"]),
+		Height:       htmlPixels(n.Attrs["height"]),
+		MaxWidth:     htmlPixels(htmlStyleProperty(n.Attrs["style"], "max-width")),
+		SizeUnit:     SizeUnitPixel,
+	}
+
+	if percent, ok := htmlPercent(n.Attrs["width"]); ok {
+		img.Width = 0
+		img.WidthPercent = percent
+	}
+
+	if percent, ok := htmlPercent(htmlStyleProperty(n.Attrs["style"], "max-width")); ok {
+		img.MaxWidth = 0
+		img.MaxWidthPercent = percent
+	}
+
+	return &img
+}
+
+func htmlPercent(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasSuffix(value, "%") {
+		return 0, false
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return percent, true
+}
+
+func htmlPixels(value string) int64 {
+	pixels, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil {
+		return 0
+	}
+
+	return int64(pixels)
+}
+
+func htmlStyleProperty(style string, property string) string {
+	for _, declaration := range strings.Split(style, ";") {
+		parts := strings.SplitN(declaration, ":", 2)
+
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), property) {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+
+	return ""
+}
+
 func (n *Node) xmlStruct() (interface{}, error) {
 	if n.Tag == "ul" || n.Tag == "ol" {
 		list, err := n.xmlListStruct()
@@ -276,6 +432,15 @@ func (n *Node) xmlStruct() (interface{}, error) {
 		return p, nil
 	}
 
+	if n.Tag == "img" {
+		p, err := (&Node{Tag: "p", Children: []*Node{n}}).xmlPStruct()
+		if err != nil {
+			return nil, errors.Wrap(err, "n.xmlPStruct")
+		}
+
+		return p, nil
+	}
+
 	if n.Tag == "li" {
 		li, err := n.xmlLiStruct()
 		if err != nil {
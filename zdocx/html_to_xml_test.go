@@ -0,0 +1,120 @@
+package zdocx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func htmlImages(t *testing.T, text string, load LoadImageFunc) []*Image {
+	t.Helper()
+
+	items, err := ItemsFromHTML(ItemsFromHTMLArgs{Text: text, LoadImage: load})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var images []*Image
+
+	for _, item := range items {
+		if p, ok := item.(*Paragraph); ok {
+			for _, text := range p.Texts {
+				if text.Image != nil {
+					images = append(images, text.Image)
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+func TestHTMLImages(t *testing.T) {
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 4, 2))
+
+	images := htmlImages(t, `<p>Chart <img src="`+src+`" alt="Sales by month" title="Sales" width="40" style="border: 0; max-width: 20px"></p>`+
+		`<img src="`+src+`" alt="">`+
+		`<img src="logo.png" role="presentation">`, func(src string) ([]byte, error) {
+		if src != "logo.png" {
+			t.Errorf("loaded %s", src)
+		}
+
+		return testPNG(t, 1, 1), nil
+	})
+
+	if len(images) != 3 {
+		t.Fatalf("got %d images", len(images))
+	}
+
+	if images[0].Description != "Sales by month" || images[0].Title != "Sales" || images[0].IsDecorative {
+		t.Errorf("alt text not taken over: %+v", images[0])
+	}
+
+	if images[0].Width != 40 || images[0].MaxWidth != 20 || images[0].SizeUnit != SizeUnitPixel {
+		t.Errorf("size not taken over: width %d, max width %d", images[0].Width, images[0].MaxWidth)
+	}
+
+	if !images[1].IsDecorative || !images[2].IsDecorative {
+		t.Error("empty alt and role=presentation are not decorative")
+	}
+
+	if images[2].FileName != "logo.png" {
+		t.Errorf("file name %s", images[2].FileName)
+	}
+}
+
+func TestHTMLStyleProperty(t *testing.T) {
+	for style, expected := range map[string]string{
+		"max-width: 20px":                 "20px",
+		"border:0;MAX-WIDTH:100%;":        "100%",
+		"min-width: 5px":                  "",
+		"background: url(a:b); max-width": "",
+	} {
+		if value := htmlStyleProperty(style, "max-width"); value != expected {
+			t.Errorf("htmlStyleProperty(%q) = %q, expected %q", style, value, expected)
+		}
+	}
+}
+
+func TestHTMLImagePercentMaxWidth(t *testing.T) {
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 4, 2))
+	images := htmlImages(t, `<img src="`+src+`" width="4000" style="max-width: 50%">`, nil)
+
+	if len(images) != 1 {
+		t.Fatalf("got %d images", len(images))
+	}
+
+	img := images[0]
+
+	if img.MaxWidth != 0 || img.MaxWidthPercent != 50 {
+		t.Fatalf("max width %d, max width percent %v", img.MaxWidth, img.MaxWidthPercent)
+	}
+
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := img.prepareMedia(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := img.populateSizes(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if width := dxaToEMU(int64(doc.containerWidth())) / 2; img.widthEMU != width || img.heightEMU != width/2 {
+		t.Errorf("size %d×%d EMU, expected %d×%d", img.widthEMU, img.heightEMU, width, width/2)
+	}
+}
@@ -0,0 +1,194 @@
+package zdocx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	contentTypeJPEG = "image/jpeg"
+	contentTypePNG  = "image/png"
+	contentTypeGIF  = "image/gif"
+	contentTypeBMP  = "image/bmp"
+	contentTypeTIFF = "image/tiff"
+	contentTypeSVG  = "image/svg+xml"
+	contentTypeWebP = "image/webp"
+)
+
+type media struct {
+	name        string
+	contentType string
+	bytes       []byte
+}
+
+func (d *Document) addMedia(data []byte, contentType string, fileName string) *media {
+	sum := sha1.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if m, ok := d.mediaByHash[hash]; ok {
+		return m
+	}
+
+	if d.mediaByHash == nil {
+		d.mediaByHash = map[string]*media{}
+	}
+
+	m := &media{
+		name:        "image_" + hash + mediaExtension(contentType, fileName),
+		contentType: contentType,
+		bytes:       data,
+	}
+
+	d.mediaByHash[hash] = m
+	d.media = append(d.media, m)
+
+	return m
+}
+
+func mediaExtension(contentType string, fileName string) string {
+	switch contentType {
+	case contentTypeJPEG:
+		return ".jpeg"
+	case contentTypePNG:
+		return ".png"
+	case contentTypeGIF:
+		return ".gif"
+	case contentTypeBMP:
+		return ".bmp"
+	case contentTypeTIFF:
+		return ".tiff"
+	case contentTypeSVG:
+		return ".svg"
+	default:
+		return strings.ToLower(filepath.Ext(fileName))
+	}
+}
+
+func detectImageContentType(data []byte) string {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return contentTypeTIFF
+	}
+
+	contentType := http.DetectContentType(data)
+
+	if strings.HasPrefix(contentType, "text/") {
+		head := data
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+
+		if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+			return contentTypeSVG
+		}
+	}
+
+	return contentType
+}
+
+func isContentTypeValid(contentType string) bool {
+	switch contentType {
+	case contentTypeJPEG, contentTypePNG, contentTypeGIF, contentTypeBMP, contentTypeTIFF, contentTypeSVG:
+		return true
+	default:
+		return false
+	}
+}
+
+func convertToPNG(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "image.Decode")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, errors.Wrap(err, "png.Encode")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func imageDPI(data []byte, contentType string) (float64, float64) {
+	var x, y float64
+
+	switch contentType {
+	case contentTypePNG:
+		x, y = pngDPI(data)
+	case contentTypeJPEG:
+		x, y = jpegDPI(data)
+	}
+
+	if x <= 0 || y <= 0 {
+		return defaultDPI, defaultDPI
+	}
+
+	return x, y
+}
+
+func pngDPI(data []byte) (float64, float64) {
+	offset := 8
+
+	for offset+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		chunkType := string(data[offset+4 : offset+8])
+		chunk := offset + 8
+
+		if chunkType == "IDAT" || chunk+length > len(data) {
+			break
+		}
+
+		// pixels per unit, unit 1 is the meter
+		if chunkType == "pHYs" && length >= 9 && data[chunk+8] == 1 {
+			x := float64(binary.BigEndian.Uint32(data[chunk:])) * 0.0254
+			y := float64(binary.BigEndian.Uint32(data[chunk+4:])) * 0.0254
+
+			return x, y
+		}
+
+		offset = chunk + length + 4
+	}
+
+	return 0, 0
+}
+
+func jpegDPI(data []byte) (float64, float64) {
+	offset := 2
+
+	for offset+4 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		segment := offset + 4
+
+		if marker == 0xDA || segment+length-2 > len(data) {
+			break
+		}
+
+		// JFIF density units: 1 is dots per inch, 2 is dots per centimeter
+		if marker == 0xE0 && length >= 16 && string(data[segment:segment+5]) == "JFIF\x00" {
+			x := float64(binary.BigEndian.Uint16(data[segment+8:]))
+			y := float64(binary.BigEndian.Uint16(data[segment+10:]))
+
+			switch data[segment+7] {
+			case 1:
+				return x, y
+			case 2:
+				return x * 2.54, y * 2.54
+			}
+
+			return 0, 0
+		}
+
+		offset = segment + length - 2
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,251 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+const testSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect width="10" height="10" fill="red"/></svg>`
+
+// a 1×1 lossless WebP
+const testWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func testImages(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	images := map[string][]byte{
+		contentTypePNG: testPNG(t, 2, 2),
+		contentTypeSVG: []byte(testSVG),
+	}
+
+	var buf bytes.Buffer
+
+	if err := gif.Encode(&buf, rgba, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	images[contentTypeGIF] = append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+
+	if err := bmp.Encode(&buf, rgba); err != nil {
+		t.Fatal(err)
+	}
+
+	images[contentTypeBMP] = append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+
+	if err := tiff.Encode(&buf, rgba, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	images[contentTypeTIFF] = append([]byte(nil), buf.Bytes()...)
+
+	webp, err := base64.StdEncoding.DecodeString(testWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images[contentTypeWebP] = webp
+
+	return images
+}
+
+func zipEntries(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := map[string][]byte{}
+
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries[f.Name], err = ioutil.ReadAll(rc)
+		rc.Close()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return entries
+}
+
+func mediaNames(entries map[string][]byte) []string {
+	var names []string
+
+	for name := range entries {
+		if strings.HasPrefix(name, "word/media/") {
+			names = append(names, strings.TrimPrefix(name, "word/media/"))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestDetectImageContentType(t *testing.T) {
+	for contentType, data := range testImages(t) {
+		if detected := detectImageContentType(data); detected != contentType {
+			t.Errorf("detected %s, expected %s", detected, contentType)
+		}
+	}
+}
+
+func TestImageFormats(t *testing.T) {
+	extensions := map[string][]string{
+		contentTypePNG:  {".png"},
+		contentTypeGIF:  {".gif"},
+		contentTypeBMP:  {".bmp"},
+		contentTypeTIFF: {".tiff"},
+		contentTypeSVG:  {".png", ".svg"},
+		contentTypeWebP: {".png"},
+	}
+
+	for contentType, data := range testImages(t) {
+		doc := NewDocument(NewDocumentArgs{})
+
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: &Image{FileName: "picture", Bytes: data}}}}); err != nil {
+			t.Errorf("%s: %v", contentType, err)
+			continue
+		}
+
+		buf, err := doc.WriteToBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries := zipEntries(t, buf.Bytes())
+		names := mediaNames(entries)
+
+		if len(names) != len(extensions[contentType]) {
+			t.Errorf("%s: media %v", contentType, names)
+			continue
+		}
+
+		for index, name := range names {
+			if !strings.HasSuffix(name, extensions[contentType][index]) {
+				t.Errorf("%s: media %s, expected %s", contentType, name, extensions[contentType][index])
+			}
+		}
+
+		if contentType == contentTypeSVG && !bytes.Contains(entries["word/document.xml"], []byte("asvg:svgBlip")) {
+			t.Error("no svgBlip for the SVG image")
+		}
+	}
+}
+
+func TestMediaSharedByHash(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	data := testPNG(t, 3, 3)
+
+	doc.Header = []*Paragraph{{Texts: []*Text{{Image: &Image{FileName: "logo.png", Bytes: data}}}}}
+
+	for index := 0; index < 2; index++ {
+		if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: &Image{FileName: "logo.png", Bytes: data}}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: &Image{FileName: "other.png", Bytes: testPNG(t, 4, 4)}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, buf.Bytes())
+
+	if names := mediaNames(entries); len(names) != 2 {
+		t.Errorf("media %v, expected one part per distinct image", names)
+	}
+
+	if count := strings.Count(string(entries["word/_rels/document.xml.rels"]), "media/image_"); count != 2 {
+		t.Errorf("%d image relationships in document.xml.rels", count)
+	}
+
+	if !strings.Contains(string(entries["word/_rels/header1.xml.rels"]), "media/image_") {
+		t.Error("header image is not related from the header part")
+	}
+}
+
+// pngWithDPI inserts a pHYs chunk right after IHDR.
+func pngWithDPI(data []byte, dpi float64) []byte {
+	chunk := make([]byte, 9)
+	binary.BigEndian.PutUint32(chunk, uint32(dpi/0.0254+0.5))
+	binary.BigEndian.PutUint32(chunk[4:], uint32(dpi/0.0254+0.5))
+	chunk[8] = 1
+
+	var buf bytes.Buffer
+
+	header := 8 + 8 + 13 + 4
+	buf.Write(data[:header])
+	binary.Write(&buf, binary.BigEndian, uint32(len(chunk)))
+	buf.WriteString("pHYs")
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte("pHYs"), chunk...)))
+	buf.Write(data[header:])
+
+	return buf.Bytes()
+}
+
+func TestImageDPI(t *testing.T) {
+	data := pngWithDPI(testPNG(t, 300, 150), 300)
+
+	if x, y := imageDPI(data, contentTypePNG); x < 299.9 || x > 300.1 || y < 299.9 || y > 300.1 {
+		t.Errorf("png dpi %v×%v", x, y)
+	}
+
+	if x, _ := imageDPI(testPNG(t, 1, 1), contentTypePNG); x != defaultDPI {
+		t.Errorf("png without pHYs has dpi %v", x)
+	}
+
+	// 300 px at 300 dpi are one inch wide
+	doc := NewDocument(NewDocumentArgs{})
+	img := &Image{Bytes: data}
+
+	if err := img.prepareMedia(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := img.populateSizes(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// pHYs stores whole pixels per meter, 300 dpi comes back as 299.9994
+	if img.widthEMU-emuPerInch > 2 || img.widthEMU < emuPerInch || img.heightEMU-emuPerInch/2 > 1 || img.heightEMU < emuPerInch/2 {
+		t.Errorf("size %d×%d EMU", img.widthEMU, img.heightEMU)
+	}
+}
+
+func TestFloatingImageLocked(t *testing.T) {
+	for unlocked, expected := range map[bool]string{false: `locked="1"`, true: `locked="0"`} {
+		img := &Image{Display: ImageDisplayFloat, Unlocked: unlocked}
+
+		if anchor := img.getDisplayTag(); !strings.Contains(anchor, expected) {
+			t.Errorf("Unlocked %v: no %s in %s", unlocked, expected, anchor)
+		}
+	}
+}
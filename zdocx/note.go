@@ -0,0 +1,316 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	footnoteTag             = "footnote"
+	endnoteTag              = "endnote"
+	footnotesID             = "fileFootnotesID"
+	endnotesID              = "fileEndnotesID"
+	NoteRestartContinuous   = "continuous"
+	NoteRestartEachSection  = "eachSect"
+	NoteRestartEachPage     = "eachPage"
+	NoteNumberFormatSymbols = "chicago"
+)
+
+type NoteOptions struct {
+	NumberFormat string
+	Restart      string
+	Start        int
+}
+
+type notePart struct {
+	partRelations
+	tag   string
+	count int
+	notes bytes.Buffer
+}
+
+func (part *notePart) relationshipType() string {
+	return "http://schemas.openxmlformats.org/officeDocument/2006/relationships/" + part.tag + "s"
+}
+
+func (part *notePart) contentType() string {
+	return "application/vnd.openxmlformats-officedocument.wordprocessingml." + part.tag + "s+xml"
+}
+
+func (part *notePart) relsID() string {
+	if part.tag == footnoteTag {
+		return footnotesID
+	}
+
+	return endnotesID
+}
+
+func (d *Document) notePart(tag string) *notePart {
+	part := &d.footnotes
+
+	if tag == endnoteTag {
+		part = &d.endnotes
+	}
+
+	if *part == nil {
+		*part = &notePart{
+			partRelations: partRelations{
+				fileName: tag + "s",
+			},
+			tag: tag,
+		}
+	}
+
+	return *part
+}
+
+func (d *Document) noteParts() []*notePart {
+	var parts []*notePart
+
+	for _, part := range []*notePart{d.footnotes, d.endnotes} {
+		if part != nil {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
+
+func noteStyleClass(tag string, name string) string {
+	if tag == footnoteTag {
+		return "Footnote" + name
+	}
+
+	return "Endnote" + name
+}
+
+type writeNoteArgs struct {
+	tag     string
+	content []interface{}
+	styles  string
+}
+
+// writeNote renders the note body into the notes part straight away, so
+// streaming documents only keep the notes themselves until Close.
+func (d *Document) writeNote(w xmlWriter, args writeNoteArgs) error {
+	if d.currentPart != nil {
+		return errors.New("notes are not supported in headers, footers and notes")
+	}
+
+	part := d.notePart(args.tag)
+	part.count++
+	id := strconv.Itoa(part.count)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<w:` + args.tag + ` w:id="` + id + `">`)
+
+	if err := d.writeAnnotation(&buf, writeAnnotationArgs{
+		part:       &part.partRelations,
+		content:    args.content,
+		styleClass: noteStyleClass(args.tag, "Text"),
+		mark:       noteMark(args.tag),
+	}); err != nil {
+		return errors.Wrap(err, "d.writeAnnotation")
+	}
+
+	buf.WriteString(`</w:` + args.tag + `>`)
+	part.notes.Write(buf.Bytes())
+
+	w.WriteString(`<w:r><w:rPr><w:rStyle w:val="` + noteStyleClass(args.tag, "Reference") + `"/>` + args.styles + `</w:rPr>`)
+	w.WriteString(`<w:` + args.tag + `Reference w:id="` + id + `"/></w:r>`)
+
+	return nil
+}
+
+type writeAnnotationArgs struct {
+	part       *partRelations
+	content    []interface{}
+	styleClass string
+	mark       string
+	paraID     string
+}
+
+// writeAnnotation writes the body of a note or a comment. Its first paragraph
+// starts with the mark run pointing back at the reference, the last one
+// carries paraID when it is set.
+func (d *Document) writeAnnotation(w xmlWriter, args writeAnnotationArgs) error {
+	content := args.content
+
+	if len(content) == 0 {
+		content = []interface{}{&Paragraph{}}
+	} else if _, ok := content[0].(*Paragraph); !ok {
+		content = append([]interface{}{&Paragraph{}}, content...)
+	}
+
+	if _, ok := content[len(content)-1].(*Paragraph); !ok && args.paraID != "" {
+		content = append(content, &Paragraph{})
+	}
+
+	isDeleting := d.isDeleting
+	revision := d.revision
+
+	d.currentPart = args.part
+	d.isDeleting = false
+	d.revision = nil
+	defer func() {
+		d.currentPart = nil
+		d.isDeleting = isDeleting
+		d.revision = revision
+	}()
+
+	for index, item := range content {
+		p, isParagraph := item.(*Paragraph)
+
+		if isParagraph {
+			if p.StyleClass == "" && p.ListParams == nil {
+				p.StyleClass = args.styleClass
+			}
+
+			if index == 0 {
+				p.mark = args.mark
+			}
+
+			if index == len(content)-1 {
+				p.paraID = args.paraID
+			}
+		}
+
+		err := writeContent(w, writeContentArgs{
+			content:  item,
+			document: d,
+		})
+
+		if isParagraph {
+			p.mark = ""
+			p.paraID = ""
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "writeContent")
+		}
+	}
+
+	return nil
+}
+
+func noteMark(tag string) string {
+	return `<w:r><w:rPr><w:rStyle w:val="` + noteStyleClass(tag, "Reference") + `"/></w:rPr><w:` + tag + `Ref/></w:r>` + getSpace()
+}
+
+func (options *NoteOptions) properties() string {
+	if options == nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+
+	if options.NumberFormat != "" {
+		buf.WriteString(`<w:numFmt w:val="` + options.NumberFormat + `"/>`)
+	}
+
+	if options.Start > 0 {
+		buf.WriteString(`<w:numStart w:val="` + strconv.Itoa(options.Start) + `"/>`)
+	}
+
+	if options.Restart != "" {
+		buf.WriteString(`<w:numRestart w:val="` + options.Restart + `"/>`)
+	}
+
+	return buf.String()
+}
+
+// Endnotes are collected at the end of a section or of the document, so they
+// can not restart on each page.
+func notesError(footnotes *NoteOptions, endnotes *NoteOptions) error {
+	if endnotes != nil && endnotes.Restart == NoteRestartEachPage {
+		return errors.New("EndnoteOptions.Restart can not be NoteRestartEachPage")
+	}
+
+	return nil
+}
+
+func notesProperties(footnotes *NoteOptions, endnotes *NoteOptions) string {
+	var buf bytes.Buffer
+
+	if footnotes != nil {
+		buf.WriteString(`<w:footnotePr>` + footnotes.properties() + `</w:footnotePr>`)
+	}
+
+	if endnotes != nil {
+		buf.WriteString(`<w:endnotePr>` + endnotes.properties() + `</w:endnotePr>`)
+	}
+
+	return buf.String()
+}
+
+// Document wide note properties in settings.xml also have to point at the
+// separator notes, Word refuses the file otherwise.
+func (d *Document) notesSettings() string {
+	var buf bytes.Buffer
+
+	for _, part := range d.noteParts() {
+		options := d.FootnoteOptions
+
+		if part.tag == endnoteTag {
+			options = d.EndnoteOptions
+		}
+
+		buf.WriteString(`<w:` + part.tag + `Pr>`)
+		buf.WriteString(options.properties())
+		buf.WriteString(`<w:` + part.tag + ` w:id="-1"/><w:` + part.tag + ` w:id="0"/>`)
+		buf.WriteString(`</w:` + part.tag + `Pr>`)
+	}
+
+	return buf.String()
+}
+
+func separatorNotes(tag string) string {
+	var buf bytes.Buffer
+
+	for id, separator := range []string{"separator", "continuationSeparator"} {
+		buf.WriteString(`<w:` + tag + ` w:type="` + separator + `" w:id="` + strconv.Itoa(id-1) + `">`)
+		buf.WriteString(`<w:p><w:pPr><w:spacing w:before="0" w:after="0" w:lineRule="auto" w:line="240"/></w:pPr>`)
+		buf.WriteString(`<w:r><w:` + separator + `/></w:r></w:p>`)
+		buf.WriteString(`</w:` + tag + `>`)
+	}
+
+	return buf.String()
+}
+
+type writeNoteFilesArgs struct {
+	document *Document
+	writer   *zip.Writer
+}
+
+func writeNoteFiles(args writeNoteFilesArgs) error {
+	for _, part := range args.document.noteParts() {
+		var buf bytes.Buffer
+
+		buf.WriteString(getDocumentStartTags(part.tag + "s"))
+		buf.WriteString(separatorNotes(part.tag))
+		buf.Write(part.notes.Bytes())
+		buf.WriteString(`</w:` + part.tag + `s>`)
+
+		file, err := args.writer.Create("word/" + part.fileName + ".xml")
+		if err != nil {
+			return errors.Wrap(err, "writer.Create")
+		}
+
+		_, err = file.Write(buf.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "file.Write")
+		}
+
+		if err := writePartRelsFile(writePartRelsFileArgs{
+			part:   &part.partRelations,
+			writer: args.writer,
+		}); err != nil {
+			return errors.Wrap(err, "writePartRelsFile")
+		}
+	}
+
+	return nil
+}
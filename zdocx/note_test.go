@@ -0,0 +1,30 @@
+package zdocx
+
+import "testing"
+
+func TestEndnotesRestartEachPage(t *testing.T) {
+	eachPage := &NoteOptions{Restart: NoteRestartEachPage}
+
+	doc := NewDocument(NewDocumentArgs{})
+	doc.FootnoteOptions = eachPage
+
+	if err := doc.SetSection(&Section{FootnoteOptions: eachPage}); err != nil {
+		t.Errorf("footnotes restarting on each page: %v", err)
+	}
+
+	if err := doc.SetSection(&Section{EndnoteOptions: eachPage}); err == nil {
+		t.Error("section endnotes restart on each page")
+	}
+
+	doc.EndnoteOptions = eachPage
+
+	if _, err := doc.WriteToBuffer(); err == nil {
+		t.Error("document endnotes restart on each page")
+	}
+
+	doc.EndnoteOptions = &NoteOptions{Restart: NoteRestartEachSection}
+
+	if _, err := doc.WriteToBuffer(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,181 @@
+package zdocx
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ProtectionForms          = "forms"
+	ProtectionReadOnly       = "readOnly"
+	ProtectionComments       = "comments"
+	ProtectionTrackedChanges = "trackedChanges"
+	protectionSpinCount      = 100000
+)
+
+// Protection restricts editing of the document to Type, with a password
+// Word asks for it to stop the protection.
+type Protection struct {
+	Type     string
+	Password string
+}
+
+func (p *Protection) Error() error {
+	switch p.Type {
+	case ProtectionForms, ProtectionReadOnly, ProtectionComments, ProtectionTrackedChanges:
+		return nil
+	default:
+		return errors.New("unknown Protection.Type " + p.Type)
+	}
+}
+
+func (d *Document) protectionSettings() (string, error) {
+	p := d.Protection
+	if p == nil {
+		return "", nil
+	}
+
+	if err := p.Error(); err != nil {
+		return "", err
+	}
+
+	settings := ""
+
+	if p.Type == ProtectionTrackedChanges {
+		settings += `<w:trackRevisions/>`
+	}
+
+	settings += `<w:documentProtection w:edit="` + p.Type + `" w:enforcement="1"`
+
+	if p.Password != "" {
+		salt := make([]byte, 16)
+
+		if _, err := rand.Read(salt); err != nil {
+			return "", errors.Wrap(err, "rand.Read")
+		}
+
+		hash := passwordHash(legacyPasswordKey(p.Password), salt, protectionSpinCount)
+
+		settings += ` w:algorithmName="SHA-512" w:hashValue="` + base64.StdEncoding.EncodeToString(hash) + `" w:saltValue="` + base64.StdEncoding.EncodeToString(salt) + `" w:spinCount="` + strconv.Itoa(protectionSpinCount) + `"`
+	}
+
+	return settings + `/>`, nil
+}
+
+// Word does not hash the password itself but the hexadecimal form of the
+// verifier earlier versions stored, as [MS-OI29500] describes for
+// documentProtection. The bytes of the verifier are written from the lowest.
+func legacyPasswordKey(password string) string {
+	hash := legacyPasswordHash(password)
+
+	return fmt.Sprintf("%02X%02X%02X%02X", byte(hash), byte(hash>>8), byte(hash>>16), byte(hash>>24))
+}
+
+var legacyPasswordInitialCodes = [15]uint16{
+	0xE1F0, 0x1D0F, 0xCC9C, 0x84C0, 0x110C, 0x0E10, 0xF1CE, 0x313E, 0x1872, 0xE139, 0xD40F, 0x84F9, 0x280C, 0xA96A, 0x4EC3,
+}
+
+var legacyPasswordMatrix = [15][7]uint16{
+	{0xAEFC, 0x4DD9, 0x9BB2, 0x2745, 0x4E8A, 0x9D14, 0x2A09},
+	{0x7B61, 0xF6C2, 0xFDA5, 0xEB6B, 0xC6F7, 0x9DCF, 0x2BBF},
+	{0x4563, 0x8AC6, 0x05AD, 0x0B5A, 0x16B4, 0x2D68, 0x5AD0},
+	{0x0375, 0x06EA, 0x0DD4, 0x1BA8, 0x3750, 0x6EA0, 0xDD40},
+	{0xD849, 0xA0B3, 0x5147, 0xA28E, 0x553D, 0xAA7A, 0x44D5},
+	{0x6F45, 0xDE8A, 0xAD35, 0x4A4B, 0x9496, 0x390D, 0x721A},
+	{0xEB23, 0xC667, 0x9CEF, 0x29FF, 0x53FE, 0xA7FC, 0x5FD9},
+	{0x47D3, 0x8FA6, 0x0F6D, 0x1EDA, 0x3DB4, 0x7B68, 0xF6D0},
+	{0xB861, 0x60E3, 0xC1C6, 0x93AD, 0x377B, 0x6EF6, 0xDDEC},
+	{0x45A0, 0x8B40, 0x06A1, 0x0D42, 0x1A84, 0x3508, 0x6A10},
+	{0xAA51, 0x4483, 0x8906, 0x022D, 0x045A, 0x08B4, 0x1168},
+	{0x76B4, 0xED68, 0xCAF1, 0x85C3, 0x1BA7, 0x374E, 0x6E9C},
+	{0x3730, 0x6E60, 0xDCC0, 0xA9A1, 0x4363, 0x86C6, 0x1DAD},
+	{0x3331, 0x6662, 0xCCC4, 0x89A9, 0x0373, 0x06E6, 0x0DCC},
+	{0x1021, 0x2042, 0x4084, 0x8108, 0x1231, 0x2462, 0x48C4},
+}
+
+// legacyPasswordHash is the 32-bit password verifier of Word 97-2003. The
+// password is cut to 15 characters of one byte each, the low byte of a
+// character unless it is zero.
+func legacyPasswordHash(password string) uint32 {
+	units := utf16.Encode([]rune(password))
+	if len(units) > 15 {
+		units = units[:15]
+	}
+
+	if len(units) == 0 {
+		return 0
+	}
+
+	chars := make([]byte, len(units))
+
+	for index, unit := range units {
+		if chars[index] = byte(unit); chars[index] == 0 {
+			chars[index] = byte(unit >> 8)
+		}
+	}
+
+	high := legacyPasswordInitialCodes[len(chars)-1]
+
+	for index, char := range chars {
+		for bit := 0; bit < 7; bit++ {
+			if char&(1<<uint(bit)) != 0 {
+				high ^= legacyPasswordMatrix[15-len(chars)+index][bit]
+			}
+		}
+	}
+
+	rotate := func(value uint16) uint16 {
+		return (value>>14)&1 | (value<<1)&0x7FFF
+	}
+
+	var low uint16
+
+	for index := len(chars) - 1; index >= 0; index-- {
+		low = rotate(low) ^ uint16(chars[index])
+	}
+
+	low = rotate(low) ^ uint16(len(chars)) ^ 0xCE4B
+
+	return uint32(high)<<16 | uint32(low)
+}
+
+// passwordHash hashes the UTF-16 password after the salt and then hashes
+// the result spinCount times more, each time followed by the iteration
+// number, as ISO/IEC 29500 describes for hashValue.
+func passwordHash(password string, salt []byte, spinCount int) []byte {
+	h := sha512.New()
+	h.Write(salt)
+	h.Write(utf16LE(password))
+	hash := h.Sum(nil)
+
+	iterator := make([]byte, 4)
+
+	for i := 0; i < spinCount; i++ {
+		binary.LittleEndian.PutUint32(iterator, uint32(i))
+
+		h.Reset()
+		h.Write(hash)
+		h.Write(iterator)
+		hash = h.Sum(hash[:0])
+	}
+
+	return hash
+}
+
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+
+	for index, unit := range units {
+		binary.LittleEndian.PutUint16(b[index*2:], unit)
+	}
+
+	return b
+}
@@ -0,0 +1,73 @@
+package zdocx
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// The verifiers are the ones Apache POI tests createXorVerifier2 with.
+func TestLegacyPasswordHash(t *testing.T) {
+	for password, expected := range map[string]uint32{
+		"Example": 0x64CEED7E,
+		"34579":   0x0005CB00,
+		"":        0,
+	} {
+		if hash := legacyPasswordHash(password); hash != expected {
+			t.Errorf("%q: %08X, expected %08X", password, hash, expected)
+		}
+	}
+
+	if key := legacyPasswordKey("Example"); key != "7EEDCE64" {
+		t.Errorf("key %s", key)
+	}
+
+	if legacyPasswordHash("pässwort-länger-als-15") != legacyPasswordHash("pässwort-länger") {
+		t.Error("the password is not cut to 15 characters")
+	}
+}
+
+func TestProtectionPasswordHash(t *testing.T) {
+	salt := make([]byte, 16)
+	for index := range salt {
+		salt[index] = byte(index)
+	}
+
+	for password, expected := range map[string]string{
+		"Example":                "zSViQTZZkgb6lsOMrmMptQGwJQFzgT20w2v9BTDj/fEq0fzZU/xgF9/oXKRxzRAF1Ce9k7x77MgJpsvdFf0eCA==",
+		"pässwort-länger-als-15": "g1SICyS9WY3R75BW5xD5W/JsI6ftpUcVEdAsUZ9Py0lqHr+Rybq9kXyC2KxHkfYNhffeI/f8vH5HhyQ1A+1KjQ==",
+	} {
+		hash := base64.StdEncoding.EncodeToString(passwordHash(legacyPasswordKey(password), salt, 100000))
+
+		if hash != expected {
+			t.Errorf("%q: %s", password, hash)
+		}
+	}
+
+	doc := NewDocument(NewDocumentArgs{})
+	doc.Protection = &Protection{Type: ProtectionReadOnly, Password: "Example"}
+
+	settings, err := doc.protectionSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := regexp.MustCompile(`w:hashValue="([^"]+)" w:saltValue="([^"]+)" w:spinCount="(\d+)"`).FindStringSubmatch(settings)
+	if match == nil {
+		t.Fatalf("no hash in %s", settings)
+	}
+
+	written, _ := base64.StdEncoding.DecodeString(match[2])
+	spinCount, _ := strconv.Atoi(match[3])
+
+	if hash := base64.StdEncoding.EncodeToString(passwordHash(legacyPasswordKey("Example"), written, spinCount)); hash != match[1] {
+		t.Errorf("hashValue %s, expected %s", match[1], hash)
+	}
+
+	doc.Protection.Type = "everything"
+
+	if _, err := doc.protectionSettings(); err == nil {
+		t.Error("unknown protection type accepted")
+	}
+}
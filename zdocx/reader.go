@@ -0,0 +1,780 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type docxPackage struct {
+	names []string
+	files map[string][]byte
+}
+
+func openPackage(data []byte) (*docxPackage, error) {
+	if IsEncrypted(data) {
+		return nil, errors.New("encrypted package, use Decrypt first")
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "zip.NewReader")
+	}
+
+	pkg := docxPackage{
+		files: map[string][]byte{},
+	}
+
+	for _, file := range reader.File {
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "readZipFile")
+		}
+
+		pkg.names = append(pkg.names, file.Name)
+		pkg.files[file.Name] = content
+	}
+
+	return &pkg, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "file.Open")
+	}
+
+	defer rc.Close()
+
+	content, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadAll")
+	}
+
+	return content, nil
+}
+
+type packageRelationship struct {
+	ID         string `xml:"Id,attr"`
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr"`
+}
+
+// relationships returns the relationships of a part by id, the part name is
+// relative to the package root, e.g. "word/document.xml".
+func (pkg *docxPackage) relationships(partName string) (map[string]packageRelationship, error) {
+	relsName := path.Join(path.Dir(partName), "_rels", path.Base(partName)+".rels")
+	relationships := map[string]packageRelationship{}
+
+	content, ok := pkg.files[relsName]
+	if !ok {
+		return relationships, nil
+	}
+
+	var rels struct {
+		Relationships []packageRelationship `xml:"Relationship"`
+	}
+
+	if err := xml.Unmarshal(content, &rels); err != nil {
+		return nil, errors.Wrap(err, "xml.Unmarshal")
+	}
+
+	for _, i := range rels.Relationships {
+		relationships[i.ID] = i
+	}
+
+	return relationships, nil
+}
+
+type documentSection struct {
+	pageSize    *PageSize
+	orientation string
+	margins     Margins
+}
+
+type documentContent struct {
+	items   []interface{}
+	section *documentSection
+}
+
+// documentReader turns word/document.xml back into the structures of this
+// package. Only what the writer itself produces is read, so documents from
+// other sources come back simplified, deleted revisions are left out.
+type documentReader struct {
+	pkg     *docxPackage
+	rels    map[string]packageRelationship
+	decoder *xml.Decoder
+	section *documentSection
+}
+
+func readDocumentContent(pkg *docxPackage) (*documentContent, error) {
+	content, ok := pkg.files["word/document.xml"]
+	if !ok {
+		return nil, errors.New("no word/document.xml")
+	}
+
+	rels, err := pkg.relationships("word/document.xml")
+	if err != nil {
+		return nil, errors.Wrap(err, "pkg.relationships")
+	}
+
+	r := documentReader{
+		pkg:     pkg,
+		rels:    rels,
+		decoder: xml.NewDecoder(bytes.NewReader(content)),
+	}
+
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no w:body")
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		if se, ok := token.(xml.StartElement); ok && se.Name.Local == "body" {
+			break
+		}
+	}
+
+	items, err := r.readBlocks("body", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "r.readBlocks")
+	}
+
+	return &documentContent{
+		items:   items,
+		section: r.section,
+	}, nil
+}
+
+func attr(se xml.StartElement, name string) string {
+	for _, i := range se.Attr {
+		if i.Name.Local == name {
+			return i.Value
+		}
+	}
+
+	return ""
+}
+
+func attrInt(se xml.StartElement, name string) int {
+	value, _ := strconv.Atoi(attr(se, name))
+
+	return value
+}
+
+func isOn(se xml.StartElement) bool {
+	value := attr(se, "val")
+
+	return value != "0" && value != "false" && value != "off"
+}
+
+// readBlocks reads paragraphs and tables until the end of the element named
+// end, elements named in properties are handed to it.
+func (r *documentReader) readBlocks(end string, properties func(se xml.StartElement) error) ([]interface{}, error) {
+	var items []interface{}
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				p, err := r.readParagraph()
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readParagraph")
+				}
+
+				if p != nil {
+					items = append(items, p)
+				}
+			case "tbl":
+				table, err := r.readTable()
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readTable")
+				}
+
+				items = append(items, table)
+			case "sdt", "sdtContent", "customXml":
+			case "sectPr":
+				if err := r.readSection(); err != nil {
+					return nil, errors.Wrap(err, "r.readSection")
+				}
+			default:
+				if properties != nil {
+					if err := properties(t); err != nil {
+						return nil, err
+					}
+
+					continue
+				}
+
+				if err := r.decoder.Skip(); err != nil {
+					return nil, errors.Wrap(err, "decoder.Skip")
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == end {
+				return items, nil
+			}
+		}
+	}
+}
+
+func (r *documentReader) readSection() error {
+	section := documentSection{}
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "pgSz":
+				section.pageSize = &PageSize{
+					Width:  attrInt(t, "w"),
+					Height: attrInt(t, "h"),
+					Code:   attrInt(t, "code"),
+				}
+				section.orientation = PageOrientationPortrait
+
+				if attr(t, "orient") == "landscape" {
+					section.orientation = PageOrientationLandscape
+				}
+			case "pgMar":
+				section.margins = Margins{
+					Top:    &Margin{Value: attrInt(t, "top")},
+					Left:   &Margin{Value: attrInt(t, "left")},
+					Bottom: &Margin{Value: attrInt(t, "bottom")},
+					Right:  &Margin{Value: attrInt(t, "right")},
+				}
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "sectPr" {
+				r.section = &section
+
+				return nil
+			}
+		}
+	}
+}
+
+// readParagraph returns nil for the empty spacing paragraph written after
+// every table and for a paragraph removed as a tracked change.
+func (r *documentReader) readParagraph() (*Paragraph, error) {
+	p := Paragraph{}
+	isSpacer := false
+	isDeleted := false
+
+	var link *Link
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "pPr":
+				isSpacer, isDeleted, err = r.readParagraphProperties(&p)
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readParagraphProperties")
+				}
+			case "r":
+				text, err := r.readRun(link)
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readRun")
+				}
+
+				if text != nil {
+					p.Texts = append(p.Texts, text)
+				}
+			case "hyperlink":
+				link = &Link{}
+
+				if rel, ok := r.rels[attr(t, "id")]; ok {
+					link.URL = rel.Target
+				}
+			case "ins", "fldSimple", "smartTag", "sdt", "sdtContent", "customXml":
+			default:
+				if err := r.decoder.Skip(); err != nil {
+					return nil, errors.Wrap(err, "decoder.Skip")
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "hyperlink":
+				link = nil
+			case "p":
+				if (isSpacer || isDeleted) && len(p.Texts) == 0 {
+					return nil, nil
+				}
+
+				return &p, nil
+			}
+		}
+	}
+}
+
+// readMarkDeleted reads the run properties of a paragraph mark.
+func (r *documentReader) readMarkDeleted() (bool, error) {
+	isDeleted := false
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return false, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "del" {
+				isDeleted = true
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return false, errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "rPr" {
+				return isDeleted, nil
+			}
+		}
+	}
+}
+
+// readParagraphProperties reports whether the paragraph is the spacer
+// written after tables and whether its mark is deleted.
+func (r *documentReader) readParagraphProperties(p *Paragraph) (bool, bool, error) {
+	isSpacer := false
+	isDeleted := false
+	hasStyle := false
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return false, false, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "pStyle":
+				hasStyle = true
+
+				if value := attr(t, "val"); value != "Normal" && value != "ListParagraph" {
+					p.StyleClass = value
+				}
+			case "numPr":
+				p.ListParams = &ListParams{
+					Type: ListBulletType,
+				}
+
+				continue
+			case "ilvl":
+				if p.ListParams != nil {
+					p.ListParams.Level = attrInt(t, "val")
+				}
+			case "numId":
+				if p.ListParams != nil {
+					switch attrInt(t, "val") {
+					case ListDecimalID:
+						p.ListParams.Type = ListDecimalType
+					case ListNoneID:
+						p.ListParams.Type = ListNoneType
+					}
+				}
+			case "jc":
+				p.Style.HorisontalAlign = attr(t, "val")
+			case "pageBreakBefore":
+				p.Style.PageBreakBefore = isOn(t)
+			case "shd":
+				p.Style.Background = attr(t, "fill")
+			case "contextualSpacing":
+				isSpacer = !hasStyle
+			case "rPr":
+				isDeleted, err = r.readMarkDeleted()
+				if err != nil {
+					return false, false, errors.Wrap(err, "r.readMarkDeleted")
+				}
+
+				continue
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return false, false, errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "pPr" {
+				return isSpacer, isDeleted, nil
+			}
+		}
+	}
+}
+
+// readRun drops the single space runs the writer puts between texts, they
+// are written again when the paragraph is.
+func (r *documentReader) readRun(link *Link) (*Text, error) {
+	text := Text{}
+
+	if link != nil {
+		text.Link = &Link{URL: link.URL}
+	}
+
+	var buf strings.Builder
+	hasProperties := false
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "rPr":
+				hasProperties = true
+
+				if err := r.readRunProperties(&text); err != nil {
+					return nil, errors.Wrap(err, "r.readRunProperties")
+				}
+
+				continue
+			case "t":
+				var value string
+
+				if err := r.decoder.DecodeElement(&value, &t); err != nil {
+					return nil, errors.Wrap(err, "decoder.DecodeElement")
+				}
+
+				buf.WriteString(value)
+
+				continue
+			case "tab":
+				buf.WriteString("\t")
+			case "br", "cr":
+				buf.WriteString("\n")
+			case "drawing":
+				if err := r.readDrawing(&text); err != nil {
+					return nil, errors.Wrap(err, "r.readDrawing")
+				}
+
+				continue
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return nil, errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local != "r" {
+				continue
+			}
+
+			text.Text = buf.String()
+
+			if text.Image == nil && text.Chart == nil && (text.Text == "" || (text.Text == " " && !hasProperties)) {
+				return nil, nil
+			}
+
+			if strings.TrimSpace(text.Text) != text.Text {
+				text.Style.SpacePreserve = true
+			}
+
+			return &text, nil
+		}
+	}
+}
+
+func (r *documentReader) readRunProperties(text *Text) error {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "rStyle":
+				if value := attr(t, "val"); value != "hyperlink" {
+					text.StyleClass = value
+				}
+			case "b":
+				text.Style.IsBold = isOn(t)
+			case "i":
+				text.Style.IsItalic = isOn(t)
+			case "color":
+				if value := attr(t, "val"); value != "auto" {
+					text.Style.Color = value
+				}
+			case "sz":
+				text.Style.FontSize = attrInt(t, "val")
+			case "rFonts":
+				text.Style.FontFamily = attr(t, "ascii")
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "rPr" {
+				return nil
+			}
+		}
+	}
+}
+
+// readDrawing reads an image or a chart into text. A chart keeps its parts
+// as they are, so it is compared and written again without being parsed.
+func (r *documentReader) readDrawing(text *Text) error {
+	img := Image{
+		SizeUnit: SizeUnitDXA,
+	}
+
+	var chart *Chart
+	var name string
+
+	hasExtent := false
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "extent":
+				if !hasExtent {
+					cx, _ := strconv.ParseInt(attr(t, "cx"), 10, 64)
+					cy, _ := strconv.ParseInt(attr(t, "cy"), 10, 64)
+					img.Width = cx / emuPerDXA
+					img.Height = cy / emuPerDXA
+					hasExtent = true
+				}
+			case "docPr":
+				name = attr(t, "name")
+				img.Description = attr(t, "descr")
+				img.Title = attr(t, "title")
+			case "blip":
+				if img.Bytes != nil {
+					continue
+				}
+
+				if rel, ok := r.rels[attr(t, "embed")]; ok {
+					img.FileName = path.Base(rel.Target)
+					img.Bytes = r.pkg.files[path.Join("word", rel.Target)]
+				}
+			case "chart":
+				if rel, ok := r.rels[attr(t, "id")]; ok {
+					chart, err = r.readChart(path.Join("word", rel.Target))
+					if err != nil {
+						return errors.Wrap(err, "r.readChart")
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local != "drawing" {
+				continue
+			}
+
+			if chart != nil {
+				chart.Name = name
+				chart.Title = img.Description
+				chart.Width = img.Width
+				chart.Height = img.Height
+				chart.SizeUnit = SizeUnitDXA
+				text.Chart = chart
+			} else if img.Bytes != nil {
+				text.Image = &img
+			}
+
+			return nil
+		}
+	}
+}
+
+func (r *documentReader) readChart(partName string) (*Chart, error) {
+	part, ok := r.pkg.files[partName]
+	if !ok {
+		return nil, nil
+	}
+
+	rels, err := r.pkg.relationships(partName)
+	if err != nil {
+		return nil, errors.Wrap(err, "pkg.relationships")
+	}
+
+	chart := Chart{
+		part: part,
+	}
+
+	for _, rel := range rels {
+		if strings.HasSuffix(rel.Type, "/package") {
+			chart.workbookPart = r.pkg.files[path.Join(path.Dir(partName), rel.Target)]
+		}
+	}
+
+	return &chart, nil
+}
+
+func (r *documentReader) readTable() (*Table, error) {
+	table := Table{}
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tblGrid":
+				continue
+			case "gridCol":
+				table.Grid = append(table.Grid, attrInt(t, "w"))
+			case "tr":
+				tr, err := r.readRow()
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readRow")
+				}
+
+				if tr != nil {
+					table.TR = append(table.TR, tr)
+				}
+
+				continue
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return nil, errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "tbl" {
+				return &table, nil
+			}
+		}
+	}
+}
+
+// readRow returns nil for a row deleted as a tracked change.
+func (r *documentReader) readRow() (*TR, error) {
+	tr := TR{}
+	isDeleted := false
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "decoder.Token")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "trPr":
+				continue
+			case "tblHeader":
+				tr.IsHeader = isOn(t)
+			case "cantSplit":
+				tr.CantSplit = isOn(t)
+			case "trHeight":
+				tr.Height = attrInt(t, "val")
+				tr.HeightRule = attr(t, "hRule")
+			case "del":
+				isDeleted = true
+			case "tc":
+				td, err := r.readCell()
+				if err != nil {
+					return nil, errors.Wrap(err, "r.readCell")
+				}
+
+				tr.TD = append(tr.TD, td)
+
+				continue
+			}
+
+			if err := r.decoder.Skip(); err != nil {
+				return nil, errors.Wrap(err, "decoder.Skip")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "tr" {
+				if isDeleted {
+					return nil, nil
+				}
+
+				return &tr, nil
+			}
+		}
+	}
+}
+
+func (r *documentReader) readCell() (*TD, error) {
+	td := TD{}
+
+	content, err := r.readBlocks("tc", func(se xml.StartElement) error {
+		if se.Name.Local != "tcPr" {
+			return r.decoder.Skip()
+		}
+
+		for {
+			token, err := r.decoder.Token()
+			if err != nil {
+				return errors.Wrap(err, "decoder.Token")
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "gridSpan":
+					td.GridSpan = attrInt(t, "val")
+				case "shd":
+					td.Style.Background = attr(t, "fill")
+				case "vAlign":
+					td.Style.VerticalAlign = attr(t, "val")
+				}
+
+				if err := r.decoder.Skip(); err != nil {
+					return errors.Wrap(err, "decoder.Skip")
+				}
+			case xml.EndElement:
+				if t.Name.Local == "tcPr" {
+					return nil
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "r.readBlocks")
+	}
+
+	td.Content = content
+
+	return &td, nil
+}
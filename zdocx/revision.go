@@ -0,0 +1,162 @@
+package zdocx
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	RevisionInsert = "ins"
+	RevisionDelete = "del"
+)
+
+type Revision struct {
+	Type   string
+	Author string
+	Date   time.Time
+}
+
+type TextStyleRevision struct {
+	Author     string
+	Date       time.Time
+	StyleClass string
+	Style      TextStyle
+}
+
+type PStyleRevision struct {
+	Author     string
+	Date       time.Time
+	StyleClass string
+	Style      PStyle
+}
+
+func (r *Revision) Error() error {
+	if r.Type != RevisionInsert && r.Type != RevisionDelete {
+		return errors.New("unknown Revision.Type " + r.Type)
+	}
+
+	if r.Author == "" {
+		return errors.New("no Revision.Author")
+	}
+
+	return nil
+}
+
+// Every revision mark needs an id unique across the document, including
+// the ones of formatting changes.
+func (d *Document) revisionAttributes(author string, date time.Time) string {
+	id := strconv.Itoa(d.revisionsCount)
+	d.revisionsCount++
+
+	attributes := ` w:id="` + id + `" w:author="` + escapeAttr(author) + `"`
+
+	if !date.IsZero() {
+		attributes += ` w:date="` + date.UTC().Format("2006-01-02T15:04:05Z") + `"`
+	}
+
+	return attributes
+}
+
+func (r *Revision) open(w xmlWriter, d *Document) {
+	if r == nil {
+		return
+	}
+
+	w.WriteString(`<w:` + r.Type + d.revisionAttributes(r.Author, r.Date) + `>`)
+	d.isDeleting = r.Type == RevisionDelete
+}
+
+func (r *Revision) close(w xmlWriter, d *Document) {
+	if r == nil {
+		return
+	}
+
+	w.WriteString(`</w:` + r.Type + `>`)
+	d.isDeleting = false
+}
+
+// mark is the empty form used for paragraph marks and table rows.
+func (r *Revision) mark(d *Document) string {
+	if r == nil {
+		return ""
+	}
+
+	return `<w:` + r.Type + d.revisionAttributes(r.Author, r.Date) + `/>`
+}
+
+func (d *Document) textTag() string {
+	if d.isDeleting {
+		return "delText"
+	}
+
+	return "t"
+}
+
+func (d *Document) instrTextTag() string {
+	if d.isDeleting {
+		return "delInstrText"
+	}
+
+	return "instrText"
+}
+
+func (d *Document) space() string {
+	if d.isDeleting {
+		return `<w:r><w:delText xml:space="preserve"> </w:delText></w:r>`
+	}
+
+	return getSpace()
+}
+
+func (r *TextStyleRevision) properties(d *Document) string {
+	if r == nil {
+		return ""
+	}
+
+	previous := Text{
+		StyleClass: r.StyleClass,
+		Style:      r.Style,
+	}
+
+	return `<w:rPrChange` + d.revisionAttributes(r.Author, r.Date) + `><w:rPr>` + previous.styleClass() + previous.styles() + `</w:rPr></w:rPrChange>`
+}
+
+func (r *PStyleRevision) properties(d *Document, listParams *ListParams) string {
+	if r == nil {
+		return ""
+	}
+
+	previous := Paragraph{
+		StyleClass: r.StyleClass,
+		ListParams: listParams,
+		Style:      r.Style,
+	}
+
+	return `<w:pPrChange` + d.revisionAttributes(r.Author, r.Date) + `><w:pPr>` + previous.getStyleClass() + previous.getListParams() + previous.getStyles() + `</w:pPr></w:pPrChange>`
+}
+
+// inheritRevision makes r the revision of the paragraph or row being
+// written, or keeps the one it inherits when r is nil, so that the content
+// without a revision of its own is shown as inserted or deleted too. The
+// returned function restores the previous revision.
+func (d *Document) inheritRevision(r *Revision) func() {
+	inherited := d.revision
+
+	if r != nil {
+		d.revision = r
+	}
+
+	return func() {
+		d.revision = inherited
+	}
+}
+
+func (d *Document) textRevision(r *Revision) *Revision {
+	if r != nil {
+		return r
+	}
+
+	return d.revision
+}
@@ -0,0 +1,53 @@
+package zdocx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRowRevisionInherited(t *testing.T) {
+	text := &Text{Text: "removed"}
+	p := &Paragraph{Texts: []*Text{text}}
+	nested := &TR{TD: []*TD{{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "nested"}}}}}}}
+	deleted := &TR{
+		Revision: &Revision{Type: RevisionDelete, Author: "Reviewer"},
+		TD: []*TD{
+			{Content: []interface{}{p}},
+			{Content: []interface{}{&Table{TR: []*TR{nested}}}},
+		},
+	}
+	kept := &Paragraph{Texts: []*Text{{Text: "kept"}}}
+
+	doc := NewDocument(NewDocumentArgs{})
+
+	if err := doc.SetTable(&Table{TR: []*TR{deleted}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := doc.SetP(kept); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Revision != nil || text.Revision != nil || nested.Revision != nil {
+		t.Error("the row revision is written into the content of the row")
+	}
+
+	body := doc.String()
+
+	for _, expected := range []string{
+		`<w:delText>removed</w:delText>`,
+		`<w:delText>nested</w:delText>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("no %s", expected)
+		}
+	}
+
+	if count := strings.Count(body, `<w:del w:id=`); count != 6 {
+		t.Errorf("%d deletion marks, expected 6", count)
+	}
+
+	if last := body[strings.LastIndex(body, "<w:p>"):]; strings.Contains(last, "<w:del") {
+		t.Error("the paragraph after the table is deleted")
+	}
+}
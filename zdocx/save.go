@@ -9,17 +9,6 @@ import (
 	"github.com/pkg/errors"
 )
 
-const (
-	mainPageFooter         = "mainPageFooter"
-	mainPageHeader         = "mainPageHeader"
-	defaultFooter          = "defaultFooter"
-	defaultHeader          = "defaultHeader"
-	mainPageHeaderFileName = "header2"
-	mainPageFooterFileName = "footer2"
-	defaultHeaderFileName  = "header1"
-	defaultFooterFileName  = "footer1"
-)
-
 type writeContentFileArgs struct {
 	document *Document
 	writer   *zip.Writer
@@ -33,7 +22,7 @@ func writeContentFile(args writeContentFileArgs) error {
 
 	args.document.writeBodyClose()
 
-	_, err = contentFile.Write(args.document.Buf.Bytes())
+	_, err = contentFile.Write(args.document.resolveCaptionLists(args.document.Buf.Bytes()))
 	if err != nil {
 		return errors.Wrap(err, "contentFile.Write")
 	}
@@ -41,334 +30,47 @@ func writeContentFile(args writeContentFileArgs) error {
 	return nil
 }
 
-type writeHeaderAndFooterFileArgs struct {
-	document *Document
-	writer   *zip.Writer
-}
-
-func writeHeaderAndFooterFile(args writeHeaderAndFooterFileArgs) error {
-	if args.document.Header != nil {
-		if err := writeHeaderOrFooter(writeHeaderOrFooterArgs{
-			document:    args.document,
-			p:           args.document.Header,
-			writer:      args.writer,
-			tag:         "hdr",
-			fileName:    defaultHeaderFileName,
-			sectionType: defaultHeader,
-		}); err != nil {
-			return errors.Wrap(err, "writeHeaderOrFooter")
-		}
-	}
-
-	if args.document.Footer != nil {
-		if err := writeHeaderOrFooter(writeHeaderOrFooterArgs{
-			document:    args.document,
-			p:           args.document.Footer,
-			writer:      args.writer,
-			tag:         "ftr",
-			fileName:    defaultFooterFileName,
-			sectionType: defaultFooter,
-		}); err != nil {
-			return errors.Wrap(err, "writeHeaderOrFooter")
-		}
-	}
-
-	if args.document.MainPageFooter != nil {
-		if err := writeHeaderOrFooter(writeHeaderOrFooterArgs{
-			document:    args.document,
-			p:           args.document.MainPageFooter,
-			writer:      args.writer,
-			tag:         "ftr",
-			fileName:    mainPageFooterFileName,
-			sectionType: mainPageFooter,
-		}); err != nil {
-			return errors.Wrap(err, "writeHeaderOrFooter")
-		}
-	}
-
-	if args.document.MainPageHeader != nil {
-		if err := writeHeaderOrFooter(writeHeaderOrFooterArgs{
-			document:    args.document,
-			p:           args.document.MainPageHeader,
-			writer:      args.writer,
-			tag:         "hdr",
-			fileName:    mainPageHeaderFileName,
-			sectionType: mainPageHeader,
-		}); err != nil {
-			return errors.Wrap(err, "writeHeaderOrFooter")
-		}
-	}
-
-	return nil
-}
-
-type writeHeaderOrFooterArgs struct {
-	document    *Document
-	p           []*Paragraph
-	writer      *zip.Writer
-	tag         string
-	fileName    string
-	sectionType string
-}
-
-func (args *writeHeaderOrFooterArgs) Error() error {
-	if args.sectionType == "" {
-		return errors.New("no args.sectionType")
-	}
-
-	if args.tag == "" {
-		return errors.New("no args.Tag")
-	}
-
-	if args.fileName == "" {
-		return errors.New("no args.FileName")
-	}
-
-	return nil
-}
-
-func writeHeaderOrFooter(args writeHeaderOrFooterArgs) error {
-	if err := args.Error(); err != nil {
-		return err
-	}
-
-	if len(args.p) == 0 {
-		return nil
-	}
-
+func imageRelationships(images []*Image) string {
 	var buf bytes.Buffer
 
-	buf.WriteString(getDocumentStartTags(args.tag))
-
-	content := []interface{}{}
-
-	for _, p := range args.p {
-		for _, i := range p.Texts {
-			if i.Image != nil {
-				switch args.sectionType {
-				case mainPageHeader:
-					i.Image.isMainPageHeader = true
-				case mainPageFooter:
-					i.Image.isMainPageFooter = true
-				case defaultHeader:
-					i.Image.isHeader = true
-				case defaultFooter:
-					i.Image.isFooter = true
-				}
-			}
-		}
-
-		p.Style.Margins = Margins{
-			Top:    &Margin{Value: 0},
-			Bottom: &Margin{Value: 0},
-		}
+	for _, i := range images {
+		buf.WriteString(`<Relationship Id="` + i.RelsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/` + i.media.name + `"/>`)
 
-		if args.sectionType == defaultFooter {
-			content = append(content, p)
-		} else {
-			pString, err := p.string(args.document)
-			if err != nil {
-				return errors.Wrap(err, "p.Bytes")
-			}
-
-			buf.WriteString(pString)
+		if i.svgMedia != nil {
+			buf.WriteString(`<Relationship Id="` + i.svgRelsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/` + i.svgMedia.name + `"/>`)
 		}
 	}
 
-	if args.sectionType == defaultFooter {
-		pagination := Paragraph{
-			isPagination: true,
-		}
-
-		style := TDStyle{
-			Margins: Margins{
-				Top:    &Margin{Value: 0},
-				Left:   &Margin{Value: 0},
-				Bottom: &Margin{Value: 0},
-				Right:  &Margin{Value: 0},
-			},
-		}
-
-		documentWidth := args.document.GetInnerWidth()
-		table := Table{
-			Type: "fixed",
-			Grid: []int{
-				int(float32(documentWidth) * 0.5),
-				int(float32(documentWidth) * 0.5),
-			},
-			TR: []*TR{
-				{
-					TD: []*TD{
-						{
-							Style:   style,
-							Content: content,
-						},
-						{
-							Style: style,
-							Content: []interface{}{
-								&pagination,
-							},
-						},
-					},
-				},
-			},
-		}
-
-		tableString, err := table.string(args.document)
-		if err != nil {
-			return errors.Wrap(err, "table.bytes")
-		}
-
-		buf.WriteString(tableString)
-	}
-
-	buf.WriteString("</w:" + args.tag + ">")
-
-	contentFile, err := args.writer.Create("word/" + args.fileName + ".xml")
-	if err != nil {
-		return errors.Wrap(err, "writer.Create")
-	}
-
-	_, err = contentFile.Write(buf.Bytes())
-	if err != nil {
-		return errors.Wrap(err, "contentFile.Write")
-	}
-
-	if err := writeHeaderOrFooterRels(writeHeaderOrFooterRelsArgs{
-		writer:      args.writer,
-		document:    args.document,
-		sectionType: args.sectionType,
-		fileName:    args.fileName,
-	}); err != nil {
-		return errors.Wrap(err, "writeHeaderOrFooterRels")
-	}
-
-	return nil
+	return buf.String()
 }
 
-type writeHeaderOrFooterRelsArgs struct {
-	writer      *zip.Writer
-	document    *Document
-	fileName    string
-	sectionType string
-}
-
-func (args *writeHeaderOrFooterRelsArgs) error() error {
-	if args.sectionType == "" {
-		return errors.New("no args.sectionType")
-	}
-
-	if args.fileName == "" {
-		return errors.New("no args.fileName")
-	}
-
-	if args.document == nil {
-		return errors.New("no args.document")
-	}
-
-	if args.writer == nil {
-		return errors.New("no args.writer")
-	}
-
-	return nil
-}
-
-func writeHeaderOrFooterRels(args writeHeaderOrFooterRelsArgs) error {
-	if err := args.error(); err != nil {
-		return err
-	}
-
-	var images []*Image
-
-	switch args.sectionType {
-	case mainPageHeader:
-		images = args.document.images.mainPageHeader
-	case mainPageFooter:
-		images = args.document.images.mainPageFooter
-	case defaultHeader:
-		images = args.document.images.header
-	case defaultFooter:
-		images = args.document.images.footer
-	}
-
-	if err := writeHeaderOrFooterRelsFile(writeHeaderOrFooterRelsFileArgs{
-		fileName: args.fileName,
-		images:   images,
-		writer:   args.writer,
-	}); err != nil {
-		return errors.Wrap(err, "writeHeaderAndFooterRelsFile")
-	}
-
-	return nil
-}
-
-type writeHeaderOrFooterRelsFileArgs struct {
-	fileName string
-	images   []*Image
-	writer   *zip.Writer
-}
-
-func writeHeaderOrFooterRelsFile(args writeHeaderOrFooterRelsFileArgs) error {
-	if len(args.images) == 0 {
-		return nil
-	}
-
-	file, err := args.writer.Create("word/_rels/" + args.fileName + ".xml.rels")
-	if err != nil {
-		return errors.Wrap(err, "writer.Create")
-	}
-
+func linkRelationships(links []*Link) string {
 	var buf bytes.Buffer
-	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	buf.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
 
-	for _, i := range args.images {
-		buf.WriteString(`<Relationship Id="` + i.RelsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/` + i.FileName + `"/>`)
-	}
-
-	buf.WriteString(`</Relationships>`)
-
-	_, err = file.Write(buf.Bytes())
-	if err != nil {
-		return errors.Wrap(err, "file.Write")
+	for _, i := range links {
+		buf.WriteString(`<Relationship Id="` + i.ID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="` + i.URL + `" TargetMode="External"/>`)
 	}
 
-	return nil
+	return buf.String()
 }
 
 type writeMediaFilesArgs struct {
-	images []*Image
+	media  []*media
 	writer *zip.Writer
 }
 
-func isContentTypeValid(contentType string) bool {
-	if contentType == "" {
-		return false
-	}
-
-	if contentType == "image/jpeg" {
-		return true
-	}
-
-	if contentType == "image/png" {
-		return true
-	}
-
-	return false
-}
-
 func writeMediaFiles(args writeMediaFilesArgs) error {
-	for _, i := range args.images {
-		if !isContentTypeValid(i.ContentType) {
+	for _, i := range args.media {
+		if !isContentTypeValid(i.contentType) {
 			continue
 		}
 
-		mediaFile, err := args.writer.Create("word/media/" + i.FileName)
+		mediaFile, err := args.writer.Create("word/media/" + i.name)
 		if err != nil {
 			return errors.Wrap(err, "writer.Create")
 		}
 
-		_, err = mediaFile.Write(i.Bytes)
+		_, err = mediaFile.Write(i.bytes)
 		if err != nil {
 			return errors.Wrap(err, "mediaFile.Write")
 		}
@@ -395,19 +97,46 @@ func (args *zipFilesArgs) Error() error {
 	return nil
 }
 
-func (doc *Document) WriteToBuffer() (*bytes.Buffer, error) {
+type WriteToBufferArgs struct {
+	Password string
+}
+
+// WriteToBuffer returns the .docx package, encrypted when args has a
+// Password. Writing closes the body, so a document is written only once.
+func (doc *Document) WriteToBuffer(args ...WriteToBufferArgs) (*bytes.Buffer, error) {
+	if doc.isStreaming() {
+		return nil, errors.New("streaming document, use Close")
+	}
+
+	if doc.closed {
+		return nil, errors.New("document already written")
+	}
+
 	b := new(bytes.Buffer)
 	writer := zip.NewWriter(b)
-	defer writer.Close()
 
 	if err := zipWrite(zipWriteArgs{
 		writer:   writer,
 		document: doc,
 	}); err != nil {
+		writer.Close()
 		return nil, errors.Wrap(err, "zipWrite")
 	}
 
-	return b, nil
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "writer.Close")
+	}
+
+	if len(args) == 0 || args[0].Password == "" {
+		return b, nil
+	}
+
+	encrypted, err := Encrypt(b.Bytes(), args[0].Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "Encrypt")
+	}
+
+	return bytes.NewBuffer(encrypted), nil
 }
 
 func zipFiles(args zipFilesArgs) error {
@@ -441,18 +170,44 @@ type zipWriteArgs struct {
 }
 
 func zipWrite(args zipWriteArgs) error {
-	if err := writeContentFile(writeContentFileArgs{
+	if !args.document.isStreaming() {
+		if err := notesError(args.document.FootnoteOptions, args.document.EndnoteOptions); err != nil {
+			return err
+		}
+
+		if err := args.document.commentsError(); err != nil {
+			return err
+		}
+
+		args.document.closed = true
+
+		if err := writeContentFile(writeContentFileArgs{
+			document: args.document,
+			writer:   args.writer,
+		}); err != nil {
+			return errors.Wrap(err, "setContent")
+		}
+	}
+
+	if err := writeHeaderAndFooterFiles(writeHeaderAndFooterFilesArgs{
 		document: args.document,
 		writer:   args.writer,
 	}); err != nil {
-		return errors.Wrap(err, "setContent")
+		return errors.Wrap(err, "writeHeaderAndFooterFiles")
 	}
 
-	if err := writeHeaderAndFooterFile(writeHeaderAndFooterFileArgs{
+	if err := writeNoteFiles(writeNoteFilesArgs{
 		document: args.document,
 		writer:   args.writer,
 	}); err != nil {
-		return errors.Wrap(err, "writeHeaderAndFooterFile")
+		return errors.Wrap(err, "writeNoteFiles")
+	}
+
+	if err := writeCommentFiles(writeCommentFilesArgs{
+		document: args.document,
+		writer:   args.writer,
+	}); err != nil {
+		return errors.Wrap(err, "writeCommentFiles")
 	}
 
 	if err := writeCorePropertiesFile(writeCorePropertiesFileArgs{
@@ -463,8 +218,8 @@ func zipWrite(args zipWriteArgs) error {
 	}
 
 	if err := writeSettingsFile(writeSettingsFileArgs{
-		writer: args.writer,
-		lang:   args.document.Lang,
+		writer:   args.writer,
+		document: args.document,
 	}); err != nil {
 		return errors.Wrap(err, "writeSettingsFile")
 	}
@@ -477,24 +232,17 @@ func zipWrite(args zipWriteArgs) error {
 	}
 
 	if err := writeMediaFiles(writeMediaFilesArgs{
-		images: args.document.images.content,
+		media:  args.document.media,
 		writer: args.writer,
 	}); err != nil {
 		return errors.Wrap(err, "writeMediaFiles")
 	}
 
-	if err := writeMediaFiles(writeMediaFilesArgs{
-		images: args.document.images.footer,
-		writer: args.writer,
-	}); err != nil {
-		return errors.Wrap(err, "writeMediaFiles")
-	}
-
-	if err := writeMediaFiles(writeMediaFilesArgs{
-		images: args.document.images.header,
+	if err := writeChartFiles(writeChartFilesArgs{
+		charts: args.document.charts,
 		writer: args.writer,
 	}); err != nil {
-		return errors.Wrap(err, "writeMediaFiles")
+		return errors.Wrap(err, "writeChartFiles")
 	}
 
 	if err := writeWordRelsFile(writeWordRelsFileArgs{
@@ -599,32 +347,30 @@ func writeWordRelsFile(args writeWordRelsFileArgs) error {
 	buf.WriteString(`<Relationship Id="rId` + settingsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>`)
 	buf.WriteString(`<Relationship Id="rId` + themeID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme" Target="theme/theme1.xml"/>`)
 
-	if len(args.document.images.content) != 0 {
-		for _, i := range args.document.images.content {
-			buf.WriteString(`<Relationship Id="` + i.RelsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/` + i.FileName + `"/>`)
-		}
-	}
+	buf.WriteString(imageRelationships(args.document.images.content))
 
-	if args.document.Header != nil {
-		buf.WriteString(`<Relationship Id="rId` + defaultHeaderID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/header" Target="` + defaultHeaderFileName + `.xml"/>`)
+	for i := range args.document.charts {
+		buf.WriteString(`<Relationship Id="` + chartRelsID(i+1) + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart" Target="charts/` + chartFileName(i+1) + `"/>`)
 	}
 
-	if args.document.Footer != nil {
-		buf.WriteString(`<Relationship Id="rId` + defaultFooterID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="` + defaultFooterFileName + `.xml"/>`)
+	for _, i := range args.document.headersAndFooters {
+		buf.WriteString(`<Relationship Id="` + i.relsID + `" Type="` + i.relationshipType() + `" Target="` + i.fileName + `.xml"/>`)
 	}
 
-	if args.document.MainPageHeader != nil {
-		buf.WriteString(`<Relationship Id="rId` + mainPageHeaderID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/header" Target="` + defaultFooterFileName + `.xml"/>`)
+	for _, i := range args.document.noteParts() {
+		buf.WriteString(`<Relationship Id="` + i.relsID() + `" Type="` + i.relationshipType() + `" Target="` + i.fileName + `.xml"/>`)
 	}
 
-	if args.document.MainPageFooter != nil {
-		buf.WriteString(`<Relationship Id="rId` + mainPageFooterID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="` + mainPageFooterFileName + `.xml"/>`)
-	}
+	if args.document.comments != nil {
+		buf.WriteString(`<Relationship Id="` + commentsID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="comments.xml"/>`)
 
-	for _, i := range args.document.Links {
-		buf.WriteString(`<Relationship Id="` + i.ID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="` + i.URL + `" TargetMode="External"/>`)
+		if args.document.comments.hasExtended() {
+			buf.WriteString(`<Relationship Id="` + commentsExtendedID + `" Type="http://schemas.microsoft.com/office/2011/relationships/commentsExtended" Target="commentsExtended.xml"/>`)
+		}
 	}
 
+	buf.WriteString(linkRelationships(args.document.Links))
+
 	buf.WriteString(`</Relationships>`)
 
 	_, err = file.Write(buf.Bytes())
@@ -696,32 +442,33 @@ func writeContentTypesFile(args writeContentTypesFileArgs) error {
 	buf.WriteString(`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>`)
 	buf.WriteString(`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>`)
 
-	for _, i := range args.document.images.content {
-		buf.WriteString(`<Override PartName="/word/media/` + i.FileName + `" ContentType="` + i.ContentType + `"/>`)
-	}
+	for _, i := range args.document.media {
+		if !isContentTypeValid(i.contentType) {
+			continue
+		}
 
-	for _, i := range args.document.images.header {
-		buf.WriteString(`<Override PartName="/word/media/` + i.FileName + `" ContentType="` + i.ContentType + `"/>`)
+		buf.WriteString(`<Override PartName="/word/media/` + i.name + `" ContentType="` + i.contentType + `"/>`)
 	}
 
-	for _, i := range args.document.images.footer {
-		buf.WriteString(`<Override PartName="/word/media/` + i.FileName + `" ContentType="` + i.ContentType + `"/>`)
+	for i := range args.document.charts {
+		buf.WriteString(`<Override PartName="/word/charts/` + chartFileName(i+1) + `" ContentType="application/vnd.openxmlformats-officedocument.drawingml.chart+xml"/>`)
+		buf.WriteString(`<Override PartName="/word/embeddings/` + chartWorkbookName(i+1) + `" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"/>`)
 	}
 
-	if args.document.Footer != nil {
-		buf.WriteString(`<Override PartName="/word/` + defaultFooterFileName + `.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>`)
+	for _, i := range args.document.headersAndFooters {
+		buf.WriteString(`<Override PartName="/word/` + i.fileName + `.xml" ContentType="` + i.contentType() + `"/>`)
 	}
 
-	if args.document.MainPageFooter != nil {
-		buf.WriteString(`<Override PartName="/word/` + mainPageFooterFileName + `.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>`)
+	for _, i := range args.document.noteParts() {
+		buf.WriteString(`<Override PartName="/word/` + i.fileName + `.xml" ContentType="` + i.contentType() + `"/>`)
 	}
 
-	if args.document.Header != nil {
-		buf.WriteString(`<Override PartName="/word/` + defaultHeaderFileName + `.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>`)
-	}
+	if args.document.comments != nil {
+		buf.WriteString(`<Override PartName="/word/comments.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.comments+xml"/>`)
 
-	if args.document.MainPageHeader != nil {
-		buf.WriteString(`<Override PartName="/word/` + mainPageHeaderFileName + `.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>`)
+		if args.document.comments.hasExtended() {
+			buf.WriteString(`<Override PartName="/word/commentsExtended.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.commentsExtended+xml"/>`)
+		}
 	}
 
 	buf.WriteString(`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>`)
@@ -744,14 +491,14 @@ func writeContentTypesFile(args writeContentTypesFileArgs) error {
 }
 
 type writeSettingsFileArgs struct {
-	lang   string
-	writer *zip.Writer
+	document *Document
+	writer   *zip.Writer
 }
 
 func writeSettingsFile(args writeSettingsFileArgs) error {
 	lang := "ru-RU"
 
-	if args.lang == "en" {
+	if args.document.Lang == "en" {
 		lang = "en-Us"
 	}
 
@@ -759,8 +506,30 @@ func writeSettingsFile(args writeSettingsFileArgs) error {
 	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
 	buf.WriteString(`<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
 	buf.WriteString(`<w:zoom w:percent="100"/>`)
+
+	if args.document.MirrorMargins {
+		buf.WriteString(`<w:mirrorMargins/>`)
+	}
+
+	protection, err := args.document.protectionSettings()
+	if err != nil {
+		return errors.Wrap(err, "document.protectionSettings")
+	}
+
+	buf.WriteString(protection)
 	buf.WriteString(`<w:defaultTabStop w:val="708"/>`)
 	buf.WriteString(`<w:autoHyphenation w:val="true"/>`)
+
+	if args.document.hasEvenHeaders {
+		buf.WriteString(`<w:evenAndOddHeaders/>`)
+	}
+
+	if args.document.UpdateFields {
+		buf.WriteString(`<w:updateFields w:val="true"/>`)
+	}
+
+	buf.WriteString(args.document.notesSettings())
+
 	buf.WriteString(`<w:compat>`)
 	buf.WriteString(`<w:compatSetting w:name="compatibilityMode" w:uri="http://schemas.microsoft.com/office/word" w:val="14"/>`)
 	buf.WriteString(`<w:compatSetting w:name="overrideTableStyleFontSizeAndJustification" w:uri="http://schemas.microsoft.com/office/word" w:val="1"/>`)
@@ -0,0 +1,52 @@
+package zdocx
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestWriteToBufferOnce(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	doc.Header = []*Paragraph{{Texts: []*Text{{Text: "header"}}}}
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Text: "text"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+
+	for _, f := range reader.File {
+		if names[f.Name] {
+			t.Errorf("duplicate zip entry %s", f.Name)
+		}
+
+		names[f.Name] = true
+	}
+
+	if !names["word/document.xml"] {
+		t.Error("no word/document.xml")
+	}
+
+	if _, err := doc.WriteToBuffer(); err == nil {
+		t.Error("second WriteToBuffer succeeded")
+	}
+
+	if err := doc.Save(SaveArgs{FileName: t.TempDir() + "/out.docx"}); err == nil {
+		t.Error("Save after WriteToBuffer succeeded")
+	}
+
+	if bytes.Count(doc.Buf.Bytes(), []byte("</w:body>")) != 1 {
+		t.Error("body closed more than once")
+	}
+}
@@ -0,0 +1,47 @@
+package zdocx
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+const svgMaxFallbackSide = 4096
+
+func svgToPNG(data []byte) ([]byte, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, errors.Wrap(err, "oksvg.ReadIconStream")
+	}
+
+	width := int(math.Ceil(icon.ViewBox.W))
+	height := int(math.Ceil(icon.ViewBox.H))
+
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("svg has no size")
+	}
+
+	if width > svgMaxFallbackSide || height > svgMaxFallbackSide {
+		scale := float64(svgMaxFallbackSide) / math.Max(float64(width), float64(height))
+		width = int(math.Max(1, float64(width)*scale))
+		height = int(math.Max(1, float64(height)*scale))
+	}
+
+	icon.SetTarget(0, 0, float64(width), float64(height))
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, rgba, rgba.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgba); err != nil {
+		return nil, errors.Wrap(err, "png.Encode")
+	}
+
+	return buf.Bytes(), nil
+}
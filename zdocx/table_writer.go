@@ -0,0 +1,138 @@
+package zdocx
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// TableWriter renders table rows one by one, so a table with a large number
+// of rows never has to be kept in memory. Table.TR, if set, is written first.
+// The last added row is held back until Close to apply the bottom border.
+// Without NewTableWriterArgs.Writer the rows go straight into the document
+// body, so nothing else may be added to the document until Close, a SetP in
+// between would end up inside the table.
+type TableWriter struct {
+	table    *Table
+	document *Document
+	writer   xmlWriter
+	buffered *bufio.Writer
+	pending  *TR
+	index    int
+	started  bool
+	closed   bool
+}
+
+type NewTableWriterArgs struct {
+	Document *Document
+	Table    *Table
+	Writer   io.Writer
+}
+
+func (args *NewTableWriterArgs) error() error {
+	if args.Document == nil {
+		return errors.New("no args.Document")
+	}
+
+	if args.Table == nil {
+		return errors.New("no args.Table")
+	}
+
+	return nil
+}
+
+func NewTableWriter(args NewTableWriterArgs) (*TableWriter, error) {
+	if err := args.error(); err != nil {
+		return nil, err
+	}
+
+	tw := TableWriter{
+		table:    args.Table,
+		document: args.Document,
+		writer:   args.Document.body(),
+	}
+
+	if args.Writer != nil {
+		tw.buffered = bufio.NewWriter(args.Writer)
+		tw.writer = tw.buffered
+	}
+
+	for _, tr := range args.Table.TR {
+		if err := tw.AddRow(tr); err != nil {
+			return nil, errors.Wrap(err, "tw.AddRow")
+		}
+	}
+
+	return &tw, nil
+}
+
+func (tw *TableWriter) AddRow(tr *TR) error {
+	if tw.closed {
+		return errors.New("table writer is closed")
+	}
+
+	if tr == nil {
+		return nil
+	}
+
+	if tw.pending != nil {
+		if err := tw.writeRow(tw.pending, false); err != nil {
+			return errors.Wrap(err, "tw.writeRow")
+		}
+	}
+
+	tw.pending = tr
+
+	return nil
+}
+
+func (tw *TableWriter) writeRow(tr *TR, isLastRow bool) error {
+	if !tw.started {
+		tw.table.writeCaption(tw.writer, tw.document, true)
+		tw.table.writeStart(tw.writer)
+		tw.started = true
+	}
+
+	if err := tr.write(tw.writer, trWriteArgs{
+		table:     tw.table,
+		index:     tw.index,
+		isLastRow: isLastRow,
+		document:  tw.document,
+	}); err != nil {
+		return errors.Wrap(err, "tr.write")
+	}
+
+	tw.index++
+
+	return nil
+}
+
+func (tw *TableWriter) Close() error {
+	if tw.closed {
+		return nil
+	}
+
+	tw.closed = true
+
+	if tw.pending != nil {
+		if err := tw.writeRow(tw.pending, true); err != nil {
+			return errors.Wrap(err, "tw.writeRow")
+		}
+
+		tw.pending = nil
+	}
+
+	if tw.started {
+		tw.table.writeEnd(tw.writer)
+		tw.table.writeCaption(tw.writer, tw.document, false)
+	}
+
+	if tw.buffered != nil {
+		if err := tw.buffered.Flush(); err != nil {
+			return errors.Wrap(err, "bufio.Writer.Flush")
+		}
+	}
+
+	return nil
+}
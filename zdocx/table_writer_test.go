@@ -0,0 +1,136 @@
+package zdocx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func testTable(rows int) *Table {
+	table := &Table{Grid: []int{3000, 3000}}
+
+	for index := 0; index < rows; index++ {
+		table.TR = append(table.TR, testRow(index))
+	}
+
+	return table
+}
+
+func testRow(index int) *TR {
+	return &TR{
+		TD: []*TD{
+			{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "row " + strconv.Itoa(index)}}}}},
+			{Content: []interface{}{&Paragraph{Texts: []*Text{{Text: "value"}}}}},
+		},
+	}
+}
+
+func TestTableWriterMatchesSetTable(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	if err := doc.SetTable(testTable(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	tw, err := NewTableWriter(NewTableWriterArgs{
+		Document: NewDocument(NewDocumentArgs{}),
+		Table:    &Table{Grid: []int{3000, 3000}},
+		Writer:   &buf,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index := 0; index < 5; index++ {
+		if err := tw.AddRow(testRow(index)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasSuffix(doc.Buf.Bytes(), buf.Bytes()) {
+		t.Errorf("TableWriter output differs from SetTable:\n%s\n%s", buf.String(), doc.Buf.String())
+	}
+
+	if err := tw.AddRow(testRow(5)); err == nil {
+		t.Error("AddRow after Close succeeded")
+	}
+}
+
+func TestSetTableErrorKeepsBody(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	before := doc.Buf.String()
+
+	table := testTable(3)
+	table.TR[2].TD[1].Content = []interface{}{&Paragraph{Texts: []*Text{{Image: &Image{Bytes: []byte("not an image")}}}}}
+
+	if err := doc.SetTable(table); err == nil {
+		t.Fatal("SetTable with a broken image succeeded")
+	}
+
+	if doc.Buf.String() != before {
+		t.Errorf("SetTable left partial XML in the body: %s", doc.Buf.String()[len(before):])
+	}
+
+	list := &List{LI: []*LI{{Items: []interface{}{table}}}}
+
+	if err := doc.SetList(list); err == nil {
+		t.Fatal("SetList with a broken image succeeded")
+	}
+
+	if doc.Buf.String() != before {
+		t.Errorf("SetList left partial XML in the body: %s", doc.Buf.String()[len(before):])
+	}
+}
+
+// BenchmarkTableWriter reports the live heap after all rows are written, it
+// stays flat as the number of rows grows.
+func BenchmarkTableWriter(b *testing.B) {
+	for _, rows := range []int{1000, 10000, 100000} {
+		b.Run(strconv.Itoa(rows), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var stats runtime.MemStats
+			var maxHeap uint64
+
+			for n := 0; n < b.N; n++ {
+				tw, err := NewTableWriter(NewTableWriterArgs{
+					Document: NewDocument(NewDocumentArgs{}),
+					Table:    &Table{Grid: []int{3000, 3000}},
+					Writer:   ioutil.Discard,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				for index := 0; index < rows; index++ {
+					if err := tw.AddRow(testRow(index)); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.StopTimer()
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+
+				if stats.HeapAlloc > maxHeap {
+					maxHeap = stats.HeapAlloc
+				}
+
+				b.StartTimer()
+
+				if err := tw.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(maxHeap), "heap-B")
+		})
+	}
+}
@@ -1,17 +1,26 @@
 package zdocx
 
 import (
+	"archive/zip"
+	"bufio"
 	"bytes"
 	"encoding/xml"
 	"fmt"
 	"image"
+	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"net/http"
+	"io"
+	"io/ioutil"
+	"math"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
 )
 
 const (
@@ -23,18 +32,12 @@ const (
 	ListNoneType           = "none"
 	TableCellDefaultMargin = 100
 	DocumentDefaultMargin  = 1440
-	PageOrientationAlbum   = "album"
-	PageOrientationBook    = "book"
 	stylesID               = "fileStylesID"
 	imagesID               = "fileImagesID"
 	numberingID            = "fileNumberingID"
 	fontTableID            = "fileFontTableID"
 	settingsID             = "fileSettingsID"
 	themeID                = "fileThemeID"
-	defaultHeaderID        = "defaultHeaderID"
-	mainPageHeaderID       = "mainPageHeaderID"
-	defaultFooterID        = "defaultFooterID"
-	mainPageFooterID       = "mainPageFooterID"
 	linkIDPrefix           = "fileLinkId"
 	PageWidth              = 12240
 	PageHeight             = 15840
@@ -52,29 +55,175 @@ const (
 	SectionTypeNextColumn  = "nextColumn"
 	SectionTypeNextPage    = "nextPage"
 	SectionTypeOddPage     = "oddPage"
+	VerticalAlignTop       = "top"
+	VerticalAlignCenter    = "center"
+	VerticalAlignBottom    = "bottom"
+	TextDirectionLrTb      = "lrTb"
+	TextDirectionTbRl      = "tbRl"
+	TextDirectionBtLr      = "btLr"
+	HeightRuleExact        = "exact"
+	HeightRuleAtLeast      = "atLeast"
+	HeightRuleAuto         = "auto"
+	TableAnchorText        = "text"
+	TableAnchorMargin      = "margin"
+	TableAnchorPage        = "page"
+	ImageShapeRect         = "rect"
+	ImageShapeRoundRect    = "roundRect"
+	ImageShapeEllipse      = "ellipse"
+	SizeUnitDXA            = "dxa"
+	SizeUnitMM             = "mm"
+	SizeUnitCM             = "cm"
+	SizeUnitInch           = "in"
+	SizeUnitPoint          = "pt"
+	SizeUnitPixel          = "px"
+	defaultDPI             = 96
+	emuPerInch             = 914400
+	emuPerPoint            = 12700
+	emuPerDXA              = 635
+	ImageWrapSquare        = "square"
+	ImageWrapTight         = "tight"
+	ImageWrapThrough       = "through"
+	ImageWrapTopAndBottom  = "topAndBottom"
+	ImageWrapBehind        = "behind"
+	ImageWrapInFront       = "inFront"
+	WrapTextBothSides      = "bothSides"
+	WrapTextLeft           = "left"
+	WrapTextRight          = "right"
+	WrapTextLargest        = "largest"
+	wrapPolygonSize        = "21600"
 )
 
+const (
+	PageOrientationAlbum     = "album"
+	PageOrientationBook      = "book"
+	PageOrientationPortrait  = "portrait"
+	PageOrientationLandscape = "landscape"
+)
+
+type PageSize struct {
+	Width  int
+	Height int
+	Code   int
+}
+
+// The presets return a new PageSize every time, so changing the page size of
+// one document does not change it for others.
+func PageSizeLetter() *PageSize {
+	return &PageSize{Width: 12240, Height: 15840, Code: 1}
+}
+
+func PageSizeLegal() *PageSize {
+	return &PageSize{Width: 12240, Height: 20160, Code: 5}
+}
+
+func PageSizeA3() *PageSize {
+	return &PageSize{Width: 16838, Height: 23811, Code: 8}
+}
+
+func PageSizeA4() *PageSize {
+	return &PageSize{Width: 11906, Height: 16838, Code: 9}
+}
+
+func PageSizeA5() *PageSize {
+	return &PageSize{Width: 8391, Height: 11906, Code: 11}
+}
+
+func CustomPageSize(width int64, height int64, unit string) *PageSize {
+	return &PageSize{
+		Width:  int(math.Round(float64(sizeToEMU(width, unit, defaultDPI)) / emuPerDXA)),
+		Height: int(math.Round(float64(sizeToEMU(height, unit, defaultDPI)) / emuPerDXA)),
+	}
+}
+
+func (size *PageSize) oriented(orientation string) (int, int) {
+	width, height := size.Width, size.Height
+
+	if isLandscape(orientation) && width < height {
+		width, height = height, width
+	}
+
+	if isPortrait(orientation) && width > height {
+		width, height = height, width
+	}
+
+	return width, height
+}
+
+func isLandscape(orientation string) bool {
+	return orientation == PageOrientationLandscape || orientation == PageOrientationAlbum
+}
+
+func isPortrait(orientation string) bool {
+	return orientation == PageOrientationPortrait || orientation == PageOrientationBook
+}
+
+type xmlWriter interface {
+	io.Writer
+	WriteString(s string) (int, error)
+}
+
 type Document struct {
-	Buf             bytes.Buffer
-	Header          []*Paragraph
-	MainPageHeader  []*Paragraph
-	Footer          []*Paragraph
-	MainPageFooter  []*Paragraph
-	PageOrientation string
-	Lang            string
-	Margins         Margins
-	FontSize        int
-	images          images
-	Links           []*Link
-	alertImage      *Image
+	Buf              bytes.Buffer
+	Header           []*Paragraph
+	MainPageHeader   []*Paragraph
+	Footer           []*Paragraph
+	MainPageFooter   []*Paragraph
+	EvenHeader       []*Paragraph
+	EvenFooter       []*Paragraph
+	LinkToPrevious   bool
+	UpdateFields     bool
+	MirrorMargins    bool
+	FooterPageNumber bool
+	PageOrientation  string
+	PageSize         *PageSize
+	PageNumberFormat string
+	PageNumberStart  int
+	FootnoteOptions  *NoteOptions
+	EndnoteOptions   *NoteOptions
+	Protection       *Protection
+	Lang             string
+	Margins          Margins
+	FontSize         int
+	images           images
+	Links            []*Link
+	alertImage       *Image
+	stream           *bufio.Writer
+	zipWriter        *zip.Writer
+	closed           bool
+	media            []*media
+	mediaByHash      map[string]*media
+	drawingsCount    int
+	containerWidths  []int
+	captionNumbers   map[string]int
+	captions         []*captionEntry
+	captionLists     []string
+	bookmarksCount   int
+	charts           []*Chart
+	footnotes        *notePart
+	endnotes         *notePart
+	comments         *commentsPart
+	revisionsCount   int
+	revision         *Revision
+	isDeleting       bool
+
+	headersAndFooters       []*headerFooterPart
+	activeHeadersAndFooters map[string]bool
+	currentPart             *partRelations
+	section                 *Section
+	contentControlsCount    int
+	hasEvenHeaders          bool
 }
 
 type images struct {
-	content        []*Image
-	mainPageHeader []*Image
-	mainPageFooter []*Image
-	header         []*Image
-	footer         []*Image
+	content []*Image
+}
+
+// partRelations collects relationships of a part written apart from
+// word/document.xml, such as a header or a footnotes part.
+type partRelations struct {
+	fileName string
+	images   []*Image
+	links    []*Link
 }
 
 type Link struct {
@@ -87,27 +236,72 @@ type Image struct {
 	Extension        string
 	ContentType      string
 	Description      string
+	Title            string
 	RelsID           string
 	HorisontalAnchor string
 	HorisontalAlign  string
 	VerticalAnchor   string
 	VerticalAlign    string
+	OffsetX          *Margin
+	OffsetY          *Margin
+	SimplePosition   *ImagePosition
+	Wrap             string
+	WrapText         string
 	Display          string
 	Width            int64
 	Height           int64
+	MaxWidth         int64
+	MaxHeight        int64
+	WidthPercent     float64
+	MaxWidthPercent  float64
+	SizeUnit         string
+	DPI              int
 	ZIndex           int
 	IsRelative       bool
 	IsBackground     bool
+	IsDecorative     bool
+	LayoutInCell     bool
+	NoOverlap        bool
+	Unlocked         bool
+	Crop             *ImageCrop
+	Rotation         float64
+	FlipHorisontal   bool
+	FlipVertical     bool
+	Outline          *Border
+	Shape            string
+	Shadow           *ImageShadow
+	Caption          *Caption
 	MarginTop        *Margin
 	MarginLeft       *Margin
 	MarginRight      *Margin
 	MarginBottom     *Margin
 	ID               int
 	Bytes            []byte
-	isMainPageHeader bool
-	isMainPageFooter bool
-	isHeader         bool
-	isFooter         bool
+	media            *media
+	svgMedia         *media
+	svgRelsID        string
+	widthEMU         int64
+	heightEMU        int64
+}
+
+type ImagePosition struct {
+	X int
+	Y int
+}
+
+type ImageCrop struct {
+	Top    float64
+	Left   float64
+	Bottom float64
+	Right  float64
+}
+
+type ImageShadow struct {
+	Color    string
+	Blur     int
+	Distance int
+	Angle    float64
+	Opacity  int
 }
 
 type ListParams struct {
@@ -129,6 +323,7 @@ type Margins struct {
 	Left   *Margin
 	Bottom *Margin
 	Right  *Margin
+	Gutter *Margin
 }
 
 type Borders struct {
@@ -145,20 +340,32 @@ type Border struct {
 }
 
 type Text struct {
-	Text       string
-	Link       *Link
-	Image      *Image
-	StyleClass string
-	Style      TextStyle
+	Text           string
+	Link           *Link
+	Image          *Image
+	Chart          *Chart
+	Field          *Field
+	Footnote       []interface{}
+	Endnote        []interface{}
+	ContentControl *ContentControl
+	Comment        *Comment
+	CommentStart   *Comment
+	CommentEnd     *Comment
+	Revision       *Revision
+	StyleRevision  *TextStyleRevision
+	StyleClass     string
+	Style          TextStyle
 }
 
 type Paragraph struct {
-	Texts      []*Text
-	ListParams *ListParams
-	StyleClass string
-	Style      PStyle
-
-	isPagination bool
+	Texts         []*Text
+	ListParams    *ListParams
+	StyleClass    string
+	Style         PStyle
+	Revision      *Revision
+	StyleRevision *PStyleRevision
+	mark          string
+	paraID        string
 }
 
 type PStyle struct {
@@ -184,10 +391,12 @@ type LI struct {
 }
 
 type TR struct {
-	TD        []*TD
-	IsHeader  bool
-	CantSplit bool
-	Height    int
+	TD         []*TD
+	IsHeader   bool
+	CantSplit  bool
+	Height     int
+	HeightRule string
+	Revision   *Revision
 }
 
 type TD struct {
@@ -198,13 +407,16 @@ type TD struct {
 }
 
 type TDStyle struct {
-	Margins    Margins
-	Borders    Borders
-	Background string
-	Color      string
-	HideMark   bool
-	FontSize   int
-	Width      int
+	Margins       Margins
+	Borders       Borders
+	Background    string
+	Color         string
+	HideMark      bool
+	NoWrap        bool
+	FontSize      int
+	Width         int
+	VerticalAlign string
+	TextDirection string
 }
 
 type Margin struct {
@@ -212,10 +424,14 @@ type Margin struct {
 }
 
 func (i *Margin) String() string {
-	return strconv.Itoa(i.Value)
+	return strconv.Itoa(i.Int())
 }
 
 func (i *Margin) Int() int {
+	if i == nil {
+		return 0
+	}
+
 	return i.Value
 }
 
@@ -240,6 +456,10 @@ func (m *Margins) IsEmpty() bool {
 		return false
 	}
 
+	if m.Gutter != nil {
+		return false
+	}
+
 	return true
 }
 
@@ -270,6 +490,19 @@ type Table struct {
 	CellMargin     *CellMargin
 	Style          TableStyle
 	NoMarginBottom bool
+	Position       *TablePosition
+	Caption        *Caption
+}
+
+type TablePosition struct {
+	HorisontalAnchor string
+	VerticalAnchor   string
+	HorisontalAlign  string
+	VerticalAlign    string
+	X                int
+	Y                int
+	Margins          Margins
+	NoOverlap        bool
 }
 
 type CellMargin struct {
@@ -296,6 +529,96 @@ func NewDocument(args NewDocumentArgs) *Document {
 	return &doc
 }
 
+type NewStreamingDocumentArgs struct {
+	Writer  io.Writer
+	Margins *Margins
+}
+
+func (args *NewStreamingDocumentArgs) error() error {
+	if args.Writer == nil {
+		return errors.New("no args.Writer")
+	}
+
+	return nil
+}
+
+// NewStreamingDocument writes word/document.xml straight into a zip written
+// to args.Writer. The remaining parts are written by Close.
+func NewStreamingDocument(args NewStreamingDocumentArgs) (*Document, error) {
+	if err := args.error(); err != nil {
+		return nil, err
+	}
+
+	doc := Document{
+		zipWriter: zip.NewWriter(args.Writer),
+	}
+
+	contentFile, err := doc.zipWriter.Create("word/document.xml")
+	if err != nil {
+		return nil, errors.Wrap(err, "writer.Create")
+	}
+
+	doc.stream = bufio.NewWriter(contentFile)
+
+	if args.Margins != nil {
+		doc.SetMargins(args.Margins)
+	}
+
+	doc.writeStartTags()
+	doc.writeBody()
+	doc.setMarginMaybe()
+	return &doc, nil
+}
+
+func (d *Document) body() xmlWriter {
+	if d.stream != nil {
+		return d.stream
+	}
+
+	return &d.Buf
+}
+
+func (d *Document) isStreaming() bool {
+	return d.zipWriter != nil
+}
+
+func (d *Document) Close() error {
+	if !d.isStreaming() {
+		return errors.New("not a streaming document")
+	}
+
+	if d.closed {
+		return nil
+	}
+
+	if err := notesError(d.FootnoteOptions, d.EndnoteOptions); err != nil {
+		return err
+	}
+
+	if err := d.commentsError(); err != nil {
+		return err
+	}
+
+	d.closed = true
+	d.writeBodyClose()
+
+	if err := d.stream.Flush(); err != nil {
+		return errors.Wrap(err, "stream.Flush")
+	}
+
+	if err := zipWrite(zipWriteArgs{
+		writer:   d.zipWriter,
+		document: d,
+	}); err != nil {
+		return errors.Wrap(err, "zipWrite")
+	}
+
+	if err := d.zipWriter.Close(); err != nil {
+		return errors.Wrap(err, "zipWriter.Close")
+	}
+
+	return nil
+}
 func (doc *Document) SetMargins(margins *Margins) {
 	if margins == nil {
 		return
@@ -316,10 +639,15 @@ func (doc *Document) SetMargins(margins *Margins) {
 	if margins.Right != nil {
 		doc.Margins.Right = margins.Right
 	}
+
+	if margins.Gutter != nil {
+		doc.Margins.Gutter = margins.Gutter
+	}
 }
 
 type SaveArgs struct {
 	FileName string
+	Password string
 }
 
 func (args *SaveArgs) Error() error {
@@ -330,24 +658,71 @@ func (args *SaveArgs) Error() error {
 	return nil
 }
 
-func (d *Document) GetInnerHeight() int {
-	pageHeight := PageHeight
+func (d *Document) getPageSize() *PageSize {
+	if d.PageSize == nil {
+		return PageSizeLetter()
+	}
+
+	return d.PageSize
+}
+
+// pageSetup returns the page of the section being written, the one set by
+// BeginSection or else the document one.
+func (d *Document) pageSetup() (*PageSize, string, Margins) {
+	size, orientation, margins := d.getPageSize(), d.PageOrientation, d.Margins
+
+	if d.section == nil {
+		return size, orientation, margins
+	}
 
-	if d.PageOrientation == PageOrientationAlbum {
-		pageHeight = PageWidth
+	if d.section.PageSize != nil {
+		size = d.section.PageSize
 	}
 
-	return pageHeight - d.Margins.Top.Int() - d.Margins.Bottom.Int()
+	if d.section.PageOrientation != "" {
+		orientation = d.section.PageOrientation
+	}
+
+	if d.section.Margins != nil {
+		margins = *d.section.Margins
+	}
+
+	return size, orientation, margins
+}
+
+func (d *Document) GetInnerHeight() int {
+	size, orientation, margins := d.pageSetup()
+	_, pageHeight := size.oriented(orientation)
+
+	return pageHeight - margins.Top.Int() - margins.Bottom.Int()
 }
 
 func (d *Document) GetInnerWidth() int {
-	pageWidth := PageWidth
+	size, orientation, margins := d.pageSetup()
 
-	if d.PageOrientation == PageOrientationAlbum {
-		pageWidth = PageHeight
+	return innerWidth(size, orientation, margins)
+}
+
+func innerWidth(size *PageSize, orientation string, margins Margins) int {
+	pageWidth, _ := size.oriented(orientation)
+
+	return pageWidth - margins.Left.Int() - margins.Right.Int() - margins.Gutter.Int()
+}
+
+func (d *Document) containerWidth() int {
+	if len(d.containerWidths) == 0 {
+		return d.GetInnerWidth()
 	}
 
-	return pageWidth - d.Margins.Left.Int() - d.Margins.Right.Int()
+	return d.containerWidths[len(d.containerWidths)-1]
+}
+
+func (d *Document) pushContainerWidth(width int) {
+	d.containerWidths = append(d.containerWidths, width)
+}
+
+func (d *Document) popContainerWidth() {
+	d.containerWidths = d.containerWidths[:len(d.containerWidths)-1]
 }
 
 func (d *Document) Save(args SaveArgs) error {
@@ -355,8 +730,29 @@ func (d *Document) Save(args SaveArgs) error {
 		return err
 	}
 
+	if d.isStreaming() {
+		return errors.New("streaming document, use Close")
+	}
+
+	if d.closed {
+		return errors.New("document already written")
+	}
+
+	if args.Password != "" {
+		buf, err := d.WriteToBuffer(WriteToBufferArgs{Password: args.Password})
+		if err != nil {
+			return errors.Wrap(err, "d.WriteToBuffer")
+		}
+
+		if err := ioutil.WriteFile(args.FileName, buf.Bytes(), 0644); err != nil {
+			return errors.Wrap(err, "ioutil.WriteFile")
+		}
+
+		return nil
+	}
+
 	if err := zipFiles(zipFilesArgs{
-		fileName: "test.docx",
+		fileName: args.FileName,
 		document: d,
 	}); err != nil {
 		return errors.Wrap(err, "ZipFiles")
@@ -366,21 +762,21 @@ func (d *Document) Save(args SaveArgs) error {
 }
 
 func (d *Document) writeStartTags() {
-	d.Buf.WriteString(getDocumentStartTags("document"))
+	d.body().WriteString(getDocumentStartTags("document"))
 }
 
 func getDocumentStartTags(tag string) string {
-	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:` + tag + ` xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:w10="urn:schemas-microsoft-com:office:word" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape" xmlns:wpg="http://schemas.microsoft.com/office/word/2010/wordprocessingGroup" xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" xmlns:wp14="http://schemas.microsoft.com/office/word/2010/wordprocessingDrawing" xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml" mc:Ignorable="w14 wp14">`
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><w:` + tag + ` xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:w10="urn:schemas-microsoft-com:office:word" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape" xmlns:wpg="http://schemas.microsoft.com/office/word/2010/wordprocessingGroup" xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" xmlns:wp14="http://schemas.microsoft.com/office/word/2010/wordprocessingDrawing" xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml" xmlns:w15="http://schemas.microsoft.com/office/word/2012/wordml" mc:Ignorable="w14 w15 wp14">`
 }
 
 func (d *Document) writeBody() {
-	d.Buf.WriteString("<w:body>")
+	d.body().WriteString("<w:body>")
 }
 
 func (d *Document) writeBodyClose() {
 	d.writeSectionProperties()
-	d.Buf.WriteString("</w:body>")
-	d.Buf.WriteString("</w:document>")
+	d.body().WriteString("</w:body>")
+	d.body().WriteString("</w:document>")
 }
 
 func (d *Document) String() string {
@@ -392,7 +788,7 @@ func (d *Document) SetSpace() {
 }
 
 func (d *Document) writeSpace() {
-	d.Buf.WriteString(getSpace())
+	d.body().WriteString(getSpace())
 	// return setSpace()
 }
 
@@ -401,56 +797,43 @@ func getSpace() string {
 }
 
 func (d *Document) SetP(p *Paragraph) error {
-	pString, err := p.string(d)
-	if err != nil {
-		return errors.Wrap(err, "p.string")
+	var buf bytes.Buffer
+
+	if err := p.write(&buf, d); err != nil {
+		return errors.Wrap(err, "p.write")
 	}
 
-	d.Buf.WriteString(pString)
+	d.body().Write(buf.Bytes())
 
 	return nil
 }
 
-func pagination() string {
-	var buf bytes.Buffer
-	buf.WriteString(`<w:p>`)
-	buf.WriteString(`<w:pPr>`)
-	buf.WriteString(`<w:widowControl w:val="false"/>`)
-	buf.WriteString(`<w:suppressLineNumbers/>`)
-	buf.WriteString(`<w:bidi w:val="0"/>`)
-	buf.WriteString(`<w:spacing w:before="0" w:after="0"/>`)
-	buf.WriteString(`<w:jc w:val="right"/>`)
-	buf.WriteString(`</w:pPr>`)
-	buf.WriteString(`<w:r>`)
-	buf.WriteString(`<w:fldChar w:fldCharType="begin"></w:fldChar>`)
-	buf.WriteString(`</w:r>`)
-	buf.WriteString(`<w:r>`)
-	buf.WriteString(`<w:instrText> PAGE </w:instrText>`)
-	buf.WriteString(`</w:r>`)
-	buf.WriteString(`<w:r>`)
-	buf.WriteString(`<w:fldChar w:fldCharType="separate"/>`)
-	buf.WriteString(`</w:r>`)
-	buf.WriteString(`<w:r>`)
-	buf.WriteString(`<w:fldChar w:fldCharType="end"/>`)
-	buf.WriteString(`</w:r>`)
-	buf.WriteString(`</w:p>`)
+func (p *Paragraph) write(w xmlWriter, d *Document) error {
+	if p.Revision != nil {
+		if err := p.Revision.Error(); err != nil {
+			return errors.Wrap(err, "Revision.Error")
+		}
+	}
 
-	return buf.String()
-}
+	defer d.inheritRevision(p.Revision)()
 
-func (p *Paragraph) string(d *Document) (string, error) {
-	if p.isPagination {
-		return pagination(), nil
-	}
+	p.writeCaptions(w, d, true)
 
-	var buf bytes.Buffer
+	if p.paraID != "" {
+		w.WriteString(`<w:p w14:paraId="` + p.paraID + `" w14:textId="77777777">`)
+	} else {
+		w.WriteString("<w:p>")
+	}
 
-	buf.WriteString("<w:p>")
-	buf.WriteString(p.properties())
+	w.WriteString(p.properties(d))
+	w.WriteString(p.mark)
 
 	for index, t := range p.Texts {
 		if index != 0 {
-			buf.WriteString(getSpace())
+			revision := d.textRevision(t.Revision)
+			revision.open(w, d)
+			w.WriteString(d.space())
+			revision.close(w, d)
 		}
 
 		if t.Style.Color == "" {
@@ -461,17 +844,31 @@ func (p *Paragraph) string(d *Document) (string, error) {
 			t.Style.FontSize = p.Style.FontSize
 		}
 
-		textString, err := t.string(d)
-		if err != nil {
-			return "", errors.Wrap(err, "Text.string")
+		if err := t.write(w, d); err != nil {
+			return errors.Wrap(err, "Text.write")
 		}
-
-		buf.WriteString(textString)
 	}
 
-	buf.WriteString("</w:p>")
+	w.WriteString("</w:p>")
+
+	p.writeCaptions(w, d, false)
 
-	return buf.String(), nil
+	return nil
+}
+
+func (p *Paragraph) writeCaptions(w xmlWriter, d *Document, above bool) {
+	for _, t := range p.Texts {
+		if t == nil || t.Image == nil {
+			continue
+		}
+
+		d.writeCaption(w, writeCaptionArgs{
+			caption:         t.Image.Caption,
+			defaultLabel:    CaptionLabelFigure,
+			defaultPosition: CaptionPositionBelow,
+			above:           above,
+		})
+	}
 }
 
 func (p *Paragraph) getListParams() string {
@@ -499,13 +896,19 @@ func (p *Paragraph) getListParams() string {
 	return buf.String()
 }
 
-func (p *Paragraph) properties() string {
+func (p *Paragraph) properties(d *Document) string {
 	var buf bytes.Buffer
 
 	buf.WriteString("<w:pPr>")
 	buf.WriteString(p.getStyleClass())
 	buf.WriteString(p.getListParams())
 	buf.WriteString(p.getStyles())
+
+	if d.revision != nil {
+		buf.WriteString("<w:rPr>" + d.revision.mark(d) + "</w:rPr>")
+	}
+
+	buf.WriteString(p.StyleRevision.properties(d, p.ListParams))
 	buf.WriteString("</w:pPr>")
 
 	return buf.String()
@@ -609,72 +1012,177 @@ func (p *Paragraph) border(tagName string, border *Border) string {
 	return `<w:` + tagName + ` w:val="` + border.Type + `" w:sz="` + strconv.Itoa(border.Width) + `" w:space="0" w:color="` + border.Color + `"/>`
 }
 
-func (t *Text) string(d *Document) (string, error) {
+func (t *Text) write(w xmlWriter, d *Document) error {
 	if t == nil {
-		return "", nil
+		return nil
+	}
+
+	if t.isEmpty() {
+		return nil
 	}
 
-	if t.Text == "" && t.Image == nil {
-		return "", nil
+	if t.Revision != nil {
+		if err := t.Revision.Error(); err != nil {
+			return errors.Wrap(err, "Revision.Error")
+		}
+	}
+
+	for _, c := range []*Comment{t.CommentStart, t.Comment} {
+		if c == nil {
+			continue
+		}
+
+		if err := d.writeCommentRangeStart(w, c); err != nil {
+			return errors.Wrap(err, "d.writeCommentRangeStart")
+		}
 	}
 
-	var buf bytes.Buffer
 	if t.Link != nil {
-		t.Link.ID = linkIDPrefix + strconv.Itoa(len(d.Links))
+		links := &d.Links
+		idPrefix := linkIDPrefix
+
+		if d.currentPart != nil {
+			links = &d.currentPart.links
+			idPrefix = d.currentPart.fileName + "LinkID"
+		}
+
+		t.Link.ID = idPrefix + strconv.Itoa(len(*links))
 
 		var linkBuf bytes.Buffer
 
 		if err := xml.EscapeText(&linkBuf, []byte(t.Link.URL)); err != nil {
-			return "", errors.Wrap(err, "xml.EscapeText")
+			return errors.Wrap(err, "xml.EscapeText")
 		}
 
 		t.Link.URL = linkBuf.String()
 
-		d.Links = append(d.Links, t.Link)
-		buf.WriteString(`<w:hyperlink r:id="` + t.Link.ID + `">`)
+		*links = append(*links, t.Link)
+		w.WriteString(`<w:hyperlink r:id="` + t.Link.ID + `">`)
 	}
 
-	if t.Image != nil {
-		imageString, err := t.Image.string(d)
+	revision := d.textRevision(t.Revision)
+
+	revision.open(w, d)
+	err := t.writeRuns(w, d)
+	revision.close(w, d)
+
+	if err != nil {
+		return errors.Wrap(err, "t.writeRuns")
+	}
+
+	if t.Link != nil {
+		w.WriteString("</w:hyperlink>")
+	}
+
+	if t.Footnote != nil || t.Endnote != nil {
+		revision.open(w, d)
+		err := t.writeNotes(w, d)
+		revision.close(w, d)
+
 		if err != nil {
-			return "", errors.Wrap(err, "t.Image.string")
+			return errors.Wrap(err, "t.writeNotes")
+		}
+	}
+
+	for _, c := range []*Comment{t.Comment, t.CommentEnd} {
+		if c == nil {
+			continue
+		}
+
+		if err := d.writeCommentRangeEnd(w, c); err != nil {
+			return errors.Wrap(err, "d.writeCommentRangeEnd")
+		}
+	}
+
+	return nil
+}
+
+func (t *Text) writeRuns(w xmlWriter, d *Document) error {
+	if t.Image != nil {
+		if err := t.Image.write(w, d); err != nil {
+			return errors.Wrap(err, "t.Image.write")
+		}
+	}
+
+	if t.Chart != nil {
+		if err := t.Chart.write(w, d); err != nil {
+			return errors.Wrap(err, "t.Chart.write")
+		}
+	}
+
+	if t.Field != nil {
+		if err := t.Field.Error(); err != nil {
+			return errors.Wrap(err, "t.Field.Error")
 		}
 
-		buf.WriteString(imageString)
+		t.Field.write(w, d, t.properties(d))
 	}
 
 	if t.Text != "" {
-		buf.WriteString("<w:r>")
-		buf.WriteString(t.properties())
-		buf.WriteString("<w:t")
+		w.WriteString("<w:r>")
+		w.WriteString(t.properties(d))
+		w.WriteString("<w:" + d.textTag())
 
 		if t.Style.SpacePreserve {
-			buf.WriteString(` xml:space="preserve"`)
+			w.WriteString(` xml:space="preserve"`)
 		}
 
-		buf.WriteString(">")
+		w.WriteString(">")
 
-		if err := xml.EscapeText(&buf, []byte(t.Text)); err != nil {
-			return "", errors.Wrap(err, "xml.EscapeText")
+		if err := xml.EscapeText(w, []byte(t.Text)); err != nil {
+			return errors.Wrap(err, "xml.EscapeText")
 		}
 
-		buf.WriteString("</w:t>")
-		buf.WriteString("</w:r>")
+		w.WriteString("</w:" + d.textTag() + ">")
+		w.WriteString("</w:r>")
 	}
 
-	if t.Link != nil {
-		buf.WriteString("</w:hyperlink>")
+	if t.ContentControl != nil {
+		if err := t.ContentControl.writeInline(w, d); err != nil {
+			return errors.Wrap(err, "t.ContentControl.writeInline")
+		}
+	}
+
+	return nil
+}
+
+func (t *Text) writeNotes(w xmlWriter, d *Document) error {
+	if t.Footnote != nil {
+		if err := d.writeNote(w, writeNoteArgs{
+			tag:     footnoteTag,
+			content: t.Footnote,
+			styles:  t.styles(),
+		}); err != nil {
+			return errors.Wrap(err, "d.writeNote")
+		}
 	}
 
-	return buf.String(), nil
+	if t.Endnote != nil {
+		if err := d.writeNote(w, writeNoteArgs{
+			tag:     endnoteTag,
+			content: t.Endnote,
+			styles:  t.styles(),
+		}); err != nil {
+			return errors.Wrap(err, "d.writeNote")
+		}
+	}
+
+	return nil
+}
+
+func (t *Text) isEmpty() bool {
+	return t.Text == "" && t.Image == nil && t.Chart == nil && t.Field == nil && t.ContentControl == nil &&
+		t.Footnote == nil && t.Endnote == nil &&
+		t.Comment == nil && t.CommentStart == nil && t.CommentEnd == nil
 }
 
-func (t *Text) properties() string {
+func (t *Text) properties(d *Document) string {
 	var buf bytes.Buffer
 
 	buf.WriteString("<w:rPr>")
 	buf.WriteString(t.styleClass())
 	buf.WriteString(t.styles())
+	buf.WriteString(t.StyleRevision.properties(d))
 	buf.WriteString("</w:rPr>")
 
 	return buf.String()
@@ -733,57 +1241,54 @@ type TextStyle struct {
 }
 
 func (d *Document) writeSectionProperties() {
-	d.Buf.WriteString("<w:sectPr>")
-
-	if d.Header != nil {
-		d.Buf.WriteString(`<w:headerReference w:type="default" r:id="rId` + defaultHeaderID + `"/>`)
-	}
-
-	if d.MainPageHeader != nil {
-		d.Buf.WriteString(`<w:headerReference w:type="first" r:id="rId` + mainPageHeaderID + `"/>`)
-	}
-
-	if d.Footer != nil {
-		d.Buf.WriteString(`<w:footerReference w:type="default" r:id="rId` + defaultFooterID + `"/>`)
-	}
-
-	if d.MainPageFooter != nil {
-		d.Buf.WriteString(`<w:footerReference w:type="first" r:id="rId` + mainPageFooterID + `"/>`)
-	}
+	d.body().WriteString("<w:sectPr>")
+
+	hasFirstPage := d.writeHeaderFooterReferences(d.body(), writeHeaderFooterReferencesArgs{
+		slots: []headerFooterSlot{
+			{tag: headerTag, reference: headerReferenceDefault, content: d.Header},
+			{tag: headerTag, reference: headerReferenceFirst, content: d.MainPageHeader},
+			{tag: headerTag, reference: headerReferenceEven, content: d.EvenHeader},
+			{tag: footerTag, reference: headerReferenceDefault, content: d.Footer},
+			{tag: footerTag, reference: headerReferenceFirst, content: d.MainPageFooter},
+			{tag: footerTag, reference: headerReferenceEven, content: d.EvenFooter},
+		},
+		linkToPrevious: d.LinkToPrevious,
+		pageNumber:     d.FooterPageNumber,
+		width:          d.GetInnerWidth(),
+	})
 
-	d.Buf.WriteString(`<w:type w:val="nextPage"/>`)
+	d.body().WriteString(notesProperties(d.FootnoteOptions, d.EndnoteOptions))
+	d.body().WriteString(`<w:type w:val="nextPage"/>`)
 	d.writePageSizes()
 	d.writeMargins()
-	d.Buf.WriteString(`<w:pgNumType w:fmt="decimal"/>`)
-	d.Buf.WriteString(`<w:formProt w:val="false"/>`)
+	d.body().WriteString(pageNumberType(d.PageNumberFormat, d.PageNumberStart))
+	d.body().WriteString(`<w:formProt w:val="false"/>`)
 
-	if d.MainPageFooter != nil || d.MainPageHeader != nil {
-		d.Buf.WriteString(`<w:titlePg/>`)
+	if hasFirstPage {
+		d.body().WriteString(`<w:titlePg/>`)
 	}
 
-	d.Buf.WriteString(`<w:textDirection w:val="lrTb"/>`)
-	d.Buf.WriteString(`<w:docGrid w:type="default" w:linePitch="100" w:charSpace="0"/>`)
+	d.body().WriteString(`<w:textDirection w:val="lrTb"/>`)
+	d.body().WriteString(`<w:docGrid w:type="default" w:linePitch="100" w:charSpace="0"/>`)
 
-	d.Buf.WriteString("</w:sectPr>")
+	d.body().WriteString("</w:sectPr>")
 }
 
 func (d *Document) writePageSizes() {
-	d.Buf.WriteString(sectionSizes(d.PageOrientation))
+	d.body().WriteString(sectionSizes(d.getPageSize(), d.PageOrientation))
 }
 
-func sectionSizes(orientation string) string {
-	width := PageWidth
-	height := PageHeight
-
-	if orientation == PageOrientationAlbum {
-		height, width = width, height
-	}
+func sectionSizes(size *PageSize, orientation string) string {
+	width, height := size.oriented(orientation)
 
 	var buf bytes.Buffer
 	buf.WriteString(`<w:pgSz w:w="` + strconv.Itoa(width) + `" w:h="` + strconv.Itoa(height) + `"`)
-	if orientation == PageOrientationAlbum {
+	if isLandscape(orientation) {
 		buf.WriteString(` w:orient="landscape"`)
 	}
+	if size.Code != 0 {
+		buf.WriteString(` w:code="` + strconv.Itoa(size.Code) + `"`)
+	}
 	buf.WriteString(` />`)
 
 	return buf.String()
@@ -808,37 +1313,39 @@ func (d *Document) setMarginMaybe() {
 }
 
 func (d *Document) writeMargins() {
-	d.Buf.WriteString(sectionMargins(d.Margins))
+	d.body().WriteString(sectionMargins(d.Margins))
 }
 
 func sectionMargins(margins Margins) string {
-	return `<w:pgMar w:left="` + margins.Left.String() + `" w:right="` + margins.Right.String() + `" w:header="` + margins.Top.String() + `" w:top="` + margins.Top.String() + `" w:footer="` + margins.Bottom.String() + `" w:bottom="` + margins.Bottom.String() + `" w:gutter="0"/>`
+	return `<w:pgMar w:left="` + margins.Left.String() + `" w:right="` + margins.Right.String() + `" w:header="` + margins.Top.String() + `" w:top="` + margins.Top.String() + `" w:footer="` + margins.Bottom.String() + `" w:bottom="` + margins.Bottom.String() + `" w:gutter="` + margins.Gutter.String() + `"/>`
 }
 
 func (d *Document) SetList(list *List) error {
-	var recursionDepth int
+	var (
+		recursionDepth int
+		buf            bytes.Buffer
+	)
 
-	listString, err := list.string(listStringArgs{
+	if err := list.write(&buf, listWriteArgs{
 		level:          0,
 		documnet:       d,
 		recursionDepth: &recursionDepth,
-	})
-	if err != nil {
-		return errors.Wrap(err, "list.string")
+	}); err != nil {
+		return errors.Wrap(err, "list.write")
 	}
 
-	d.Buf.WriteString(listString)
+	d.body().Write(buf.Bytes())
 
 	return nil
 }
 
-type listStringArgs struct {
+type listWriteArgs struct {
 	level          int
 	recursionDepth *int
 	documnet       *Document
 }
 
-func (args *listStringArgs) error() error {
+func (args *listWriteArgs) error() error {
 	if args.recursionDepth == nil {
 		return errors.New("no args.recursionDepth")
 	}
@@ -850,65 +1357,57 @@ func (args *listStringArgs) error() error {
 	return nil
 }
 
-func (list *List) string(args listStringArgs) (string, error) {
+func (list *List) write(w xmlWriter, args listWriteArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
 	}
 
 	if *args.recursionDepth >= 1000 {
-		return "", errors.New("infinity loop")
+		return errors.New("infinity loop")
 	}
 
 	*args.recursionDepth++
 
 	if list.LI == nil {
-		return "", nil
+		return nil
 	}
 
-	var buf bytes.Buffer
-
 	for _, li := range list.LI {
 		for index, i := range li.Items {
 			switch i.(type) {
 			case *Paragraph:
-				pString, err := listPString(listPStringArgs{
+				if err := listPWrite(w, listPWriteArgs{
 					index:    index,
 					listType: ListBulletType,
 					level:    args.level,
 					item:     i,
 					style:    list.Style,
 					document: args.documnet,
-				})
-				if err != nil {
-					return "", errors.Wrap(err, "listPString")
+				}); err != nil {
+					return errors.Wrap(err, "listPWrite")
 				}
 
-				buf.WriteString(pString)
-
 			case *List:
-				listString, err := listInListString(listInListStringArgs{
+				if err := listInListWrite(w, listInListWriteArgs{
 					item:           i,
 					level:          args.level + 1,
 					recursionDepth: args.recursionDepth,
 					listType:       ListBulletType,
 					document:       args.documnet,
-				})
-				if err != nil {
-					return "", errors.Wrap(err, "setListInList")
+				}); err != nil {
+					return errors.Wrap(err, "listInListWrite")
 				}
 
-				buf.WriteString(listString)
-
 			default:
-				return "", errors.New("undefined item type")
+				return errors.New("undefined item type")
 			}
 		}
 	}
 
-	return buf.String(), nil
+	return nil
 }
 
-type listInListStringArgs struct {
+type listInListWriteArgs struct {
 	item           interface{}
 	level          int
 	recursionDepth *int
@@ -916,7 +1415,7 @@ type listInListStringArgs struct {
 	document       *Document
 }
 
-func (args *listInListStringArgs) error() error {
+func (args *listInListWriteArgs) error() error {
 	if args.document == nil {
 		return errors.New("no args.document")
 	}
@@ -924,31 +1423,30 @@ func (args *listInListStringArgs) error() error {
 	return nil
 }
 
-func listInListString(args listInListStringArgs) (string, error) {
+func listInListWrite(w xmlWriter, args listInListWriteArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
 	}
 
 	list, ok := args.item.(*List)
 	if !ok {
-		return "", errors.New("can't convert to List")
+		return errors.New("can't convert to List")
 	}
 
 	list.Type = args.listType
 
-	listString, err := list.string(listStringArgs{
+	if err := list.write(w, listWriteArgs{
 		level:          args.level,
 		recursionDepth: args.recursionDepth,
 		documnet:       args.document,
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "list.string")
+	}); err != nil {
+		return errors.Wrap(err, "list.write")
 	}
 
-	return listString, nil
+	return nil
 }
 
-type listPStringArgs struct {
+type listPWriteArgs struct {
 	item     interface{}
 	index    int
 	listType string
@@ -957,7 +1455,7 @@ type listPStringArgs struct {
 	document *Document
 }
 
-func (args *listPStringArgs) error() error {
+func (args *listPWriteArgs) error() error {
 	if args.document == nil {
 		return errors.New("no args.document")
 	}
@@ -965,14 +1463,14 @@ func (args *listPStringArgs) error() error {
 	return nil
 }
 
-func listPString(args listPStringArgs) (string, error) {
+func listPWrite(w xmlWriter, args listPWriteArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
 	}
 
 	item, ok := args.item.(*Paragraph)
 	if !ok {
-		return "", errors.New("can't convert to Paragraph")
+		return errors.New("can't convert to Paragraph")
 	}
 
 	item.Style.Color = args.style.Color
@@ -994,12 +1492,11 @@ func listPString(args listPStringArgs) (string, error) {
 		item.Style.Margins.Left.Value = 720 * (args.level + 1)
 	}
 
-	pString, err := item.string(args.document)
-	if err != nil {
-		return "", errors.Wrap(err, "Document.writeP")
+	if err := item.write(w, args.document); err != nil {
+		return errors.Wrap(err, "item.write")
 	}
 
-	return pString, nil
+	return nil
 }
 
 func (td *TD) border(tagName string, border Border) string {
@@ -1018,11 +1515,12 @@ func (td *TD) border(tagName string, border Border) string {
 	return `<w:` + tagName + ` w:val="` + border.Type + `" w:sz="` + strconv.Itoa(border.Width) + `" w:space="0" w:color="` + border.Color + `"/>`
 }
 
-type tdBytesArgs struct {
+type tdWriteArgs struct {
 	document *Document
+	width    int
 }
 
-func (args *tdBytesArgs) error() error {
+func (args *tdWriteArgs) error() error {
 	if args.document == nil {
 		return errors.New("no args.document")
 	}
@@ -1030,13 +1528,48 @@ func (args *tdBytesArgs) error() error {
 	return nil
 }
 
-func (td *TD) string(args tdBytesArgs) (string, error) {
+func (td *TD) write(w xmlWriter, args tdWriteArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
+	}
+
+	w.WriteString("<w:tc>")
+	w.WriteString(td.properties())
+
+	if args.width > 0 {
+		width := args.width
+
+		if td.Style.Margins.Left != nil {
+			width -= td.Style.Margins.Left.Int()
+		}
+
+		if td.Style.Margins.Right != nil {
+			width -= td.Style.Margins.Right.Int()
+		}
+
+		args.document.pushContainerWidth(width)
+		defer args.document.popContainerWidth()
+	}
+
+	for _, content := range td.Content {
+		if err := writeContent(w, writeContentArgs{
+			content:  content,
+			document: args.document,
+			color:    td.Style.Color,
+			fontSize: td.Style.FontSize,
+		}); err != nil {
+			return errors.Wrap(err, "writeContent")
+		}
 	}
 
+	w.WriteString("</w:tc>")
+
+	return nil
+}
+
+func (td *TD) properties() string {
 	var buf bytes.Buffer
-	buf.WriteString("<w:tc>")
+
 	buf.WriteString("<w:tcPr>")
 
 	if td.GridSpan > 0 {
@@ -1062,6 +1595,10 @@ func (td *TD) string(args tdBytesArgs) (string, error) {
 		buf.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="` + td.Style.Background + `"/>`)
 	}
 
+	if td.Style.NoWrap {
+		buf.WriteString(`<w:noWrap/>`)
+	}
+
 	if !td.Style.Margins.IsEmpty() {
 		buf.WriteString(`<w:tcMar>`)
 
@@ -1084,25 +1621,17 @@ func (td *TD) string(args tdBytesArgs) (string, error) {
 		buf.WriteString(`</w:tcMar>`)
 	}
 
-	buf.WriteString("</w:tcPr>")
-
-	for _, content := range td.Content {
-		content, err := contentFromInterface(contentFromInterfaceArgs{
-			content:  content,
-			document: args.document,
-			color:    td.Style.Color,
-			fontSize: td.Style.FontSize,
-		})
-		if err != nil {
-			return "", errors.Wrap(err, "contentFromInterface")
-		}
+	if td.Style.TextDirection != "" {
+		buf.WriteString(`<w:textDirection w:val="` + td.Style.TextDirection + `"/>`)
+	}
 
-		buf.WriteString(content)
+	if td.Style.VerticalAlign != "" {
+		buf.WriteString(`<w:vAlign w:val="` + td.Style.VerticalAlign + `"/>`)
 	}
 
-	buf.WriteString("</w:tc>")
+	buf.WriteString("</w:tcPr>")
 
-	return buf.String(), nil
+	return buf.String()
 }
 
 func contextualSpacing(hidden bool) string {
@@ -1134,17 +1663,17 @@ func contextualSpacing(hidden bool) string {
 }
 
 func (d *Document) writeContextualSpacing(hidden bool) {
-	d.Buf.WriteString(contextualSpacing(hidden))
+	d.body().WriteString(contextualSpacing(hidden))
 }
 
-type contentFromInterfaceArgs struct {
+type writeContentArgs struct {
 	content  interface{}
 	document *Document
 	color    string
 	fontSize int
 }
 
-func (args *contentFromInterfaceArgs) error() error {
+func (args *writeContentArgs) error() error {
 	if args.document == nil {
 		return errors.New("no args.document")
 	}
@@ -1152,15 +1681,15 @@ func (args *contentFromInterfaceArgs) error() error {
 	return nil
 }
 
-func contentFromInterface(args contentFromInterfaceArgs) (string, error) {
+func writeContent(w xmlWriter, args writeContentArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
 	}
 	switch args.content.(type) {
 	case *Paragraph:
 		p, ok := args.content.(*Paragraph)
 		if !ok {
-			return "", errors.New("can't convert to Paragraph")
+			return errors.New("can't convert to Paragraph")
 		}
 
 		if p.Style.Color == "" {
@@ -1171,17 +1700,16 @@ func contentFromInterface(args contentFromInterfaceArgs) (string, error) {
 			p.Style.FontSize = args.fontSize
 		}
 
-		pString, err := p.string(args.document)
-		if err != nil {
-			return "", errors.Wrap(err, "p.string")
+		if err := p.write(w, args.document); err != nil {
+			return errors.Wrap(err, "p.write")
 		}
 
-		return pString, nil
+		return nil
 
 	case *List:
 		list, ok := args.content.(*List)
 		if !ok {
-			return "", errors.New("can't convert to List")
+			return errors.New("can't convert to List")
 		}
 
 		if list.Style.Color == "" {
@@ -1193,25 +1721,24 @@ func contentFromInterface(args contentFromInterfaceArgs) (string, error) {
 		}
 
 		var recursionDepth int
-		listString, err := list.string(listStringArgs{
+		if err := list.write(w, listWriteArgs{
 			level:          0,
 			documnet:       args.document,
 			recursionDepth: &recursionDepth,
-		})
-		if err != nil {
-			return "", errors.Wrap(err, "list.string")
+		}); err != nil {
+			return errors.Wrap(err, "list.write")
 		}
 
-		return listString, nil
+		return nil
 
 	case *Table:
 		table, ok := args.content.(*Table)
 		if !ok {
-			return "", errors.New("can't convert to table")
+			return errors.New("can't convert to table")
 		}
 
 		if table == nil {
-			return "", nil
+			return nil
 		}
 
 		if table.Style.Color == "" {
@@ -1222,26 +1749,42 @@ func contentFromInterface(args contentFromInterfaceArgs) (string, error) {
 			table.Style.FontSize = args.fontSize
 		}
 
-		tableString, err := table.string(args.document)
-		if err != nil {
-			return "", errors.Wrap(err, "table.string")
+		if err := table.write(w, args.document); err != nil {
+			return errors.Wrap(err, "table.write")
+		}
+
+		return nil
+
+	case *ContentControl:
+		cc, ok := args.content.(*ContentControl)
+		if !ok {
+			return errors.New("can't convert to ContentControl")
 		}
 
-		return tableString, nil
+		if err := cc.write(w, args.document); err != nil {
+			return errors.Wrap(err, "cc.write")
+		}
+
+		return nil
 
 	default:
 		println(fmt.Sprintf("%T", args.content))
-		return "", errors.New("undefined item type")
+		return errors.New("undefined item type")
 	}
 }
 
-type trStringArgs struct {
-	table    *Table
-	index    int
-	document *Document
+type trWriteArgs struct {
+	table     *Table
+	index     int
+	isLastRow bool
+	document  *Document
 }
 
-func (args *trStringArgs) error() error {
+func (args *trWriteArgs) error() error {
+	if args.table == nil {
+		return errors.New("no args.table")
+	}
+
 	if args.document == nil {
 		return errors.New("no args.document")
 	}
@@ -1249,45 +1792,64 @@ func (args *trStringArgs) error() error {
 	return nil
 }
 
-func (tr *TR) string(args trStringArgs) (string, error) {
+func (tr *TR) write(w xmlWriter, args trWriteArgs) error {
 	if err := args.error(); err != nil {
-		return "", err
+		return err
 	}
 
 	if tr.TD == nil {
-		return "", nil
+		return nil
 	}
 
-	var buf bytes.Buffer
+	if tr.Revision != nil {
+		if err := tr.Revision.Error(); err != nil {
+			return errors.Wrap(err, "Revision.Error")
+		}
+	}
+
+	defer args.document.inheritRevision(tr.Revision)()
 
-	buf.WriteString("<w:tr>")
-	buf.WriteString(tr.properties())
+	w.WriteString("<w:tr>")
+	w.WriteString(tr.properties(args.document))
+
+	column := 0
 
 	for index, td := range tr.TD {
+		span := td.GridSpan
+		if span < 1 {
+			span = 1
+		}
+
+		width := td.Style.Width
+		if width == 0 {
+			width = args.table.columnsWidth(column, span)
+		}
+
+		column += span
+
 		// td.prepareBorders(args.table.Style.Borders)
 		td.setBorderMaybe(setBorderMaybeArgs{
 			table:      args.table,
 			trIndex:    args.index,
+			isLastRow:  args.isLastRow,
 			tdIndex:    index,
 			tdTotalCnt: len(tr.TD),
 		})
 
-		td, err := td.string(tdBytesArgs{
+		if err := td.write(w, tdWriteArgs{
 			document: args.document,
-		})
-		if err != nil {
-			return "", errors.Wrap(err, "td.string")
+			width:    width,
+		}); err != nil {
+			return errors.Wrap(err, "td.write")
 		}
-
-		buf.WriteString(td)
 	}
 
-	buf.WriteString("</w:tr>")
+	w.WriteString("</w:tr>")
 
-	return buf.String(), nil
+	return nil
 }
 
-func (tr *TR) properties() string {
+func (tr *TR) properties(d *Document) string {
 	var buf bytes.Buffer
 
 	buf.WriteString(`<w:trPr>`)
@@ -1301,17 +1863,31 @@ func (tr *TR) properties() string {
 	}
 
 	if tr.Height > 0 {
-		buf.WriteString(`<w:trHeight w:hRule="exact" w:val="` + strconv.Itoa(tr.Height) + `" />`)
+		buf.WriteString(`<w:trHeight w:hRule="` + tr.getHeightRule() + `" w:val="` + strconv.Itoa(tr.Height) + `" />`)
 	}
 
+	buf.WriteString(d.revision.mark(d))
+
 	buf.WriteString(`</w:trPr>`)
 
 	return buf.String()
 }
 
+func (tr *TR) getHeightRule() string {
+	switch tr.HeightRule {
+	case HeightRuleAtLeast:
+		return HeightRuleAtLeast
+	case HeightRuleAuto:
+		return HeightRuleAuto
+	default:
+		return HeightRuleExact
+	}
+}
+
 type setBorderMaybeArgs struct {
 	table      *Table
 	trIndex    int
+	isLastRow  bool
 	tdTotalCnt int
 	tdIndex    int
 }
@@ -1329,55 +1905,72 @@ func (td *TD) setBorderMaybe(args setBorderMaybeArgs) {
 		td.Style.Borders.Right = args.table.Style.Borders.Right
 	}
 
-	if args.trIndex == len(args.table.TR)-1 && !args.table.Style.Borders.Bottom.isEmpty() {
+	if args.isLastRow && !args.table.Style.Borders.Bottom.isEmpty() {
 		td.Style.Borders.Bottom = args.table.Style.Borders.Bottom
 	}
 }
 
-func (t *Table) string(d *Document) (string, error) {
-	var buf bytes.Buffer
-
+func (t *Table) write(w xmlWriter, d *Document) error {
 	if t.TR == nil {
-		return "", nil
+		return nil
 	}
 
-	buf.WriteString("<w:tbl>")
-	buf.WriteString(t.properties())
-	buf.WriteString(t.GetGrid())
+	t.writeCaption(w, d, true)
+	t.writeStart(w)
 
-	rows, err := t.rowsString(d)
-	if err != nil {
-		return "", errors.Wrap(err, "t.rowsBytes")
+	if err := t.writeRows(w, d); err != nil {
+		return errors.Wrap(err, "t.writeRows")
 	}
 
-	buf.WriteString(rows)
-	buf.WriteString("</w:tbl>")
-	buf.WriteString(contextualSpacing(t.NoMarginBottom))
+	t.writeEnd(w)
+	t.writeCaption(w, d, false)
+
+	return nil
+}
 
-	return buf.String(), nil
+func (t *Table) writeCaption(w xmlWriter, d *Document, above bool) {
+	d.writeCaption(w, writeCaptionArgs{
+		caption:         t.Caption,
+		defaultLabel:    CaptionLabelTable,
+		defaultPosition: CaptionPositionAbove,
+		above:           above,
+	})
 }
 
-func (t *Table) rowsString(d *Document) (string, error) {
-	if t.TR == nil {
-		return "", nil
-	}
+func (t *Table) writeStart(w xmlWriter) {
+	w.WriteString("<w:tbl>")
+	w.WriteString(t.properties())
+	w.WriteString(t.GetGrid())
+}
 
-	var buf bytes.Buffer
+func (t *Table) writeEnd(w xmlWriter) {
+	w.WriteString("</w:tbl>")
+	w.WriteString(contextualSpacing(t.NoMarginBottom))
+}
 
+func (t *Table) writeRows(w xmlWriter, d *Document) error {
 	for index, tr := range t.TR {
-		trString, err := tr.string(trStringArgs{
-			index:    index,
-			table:    t,
-			document: d,
-		})
-		if err != nil {
-			return "", errors.Wrap(err, "tr.string")
+		if err := tr.write(w, trWriteArgs{
+			index:     index,
+			isLastRow: index == len(t.TR)-1,
+			table:     t,
+			document:  d,
+		}); err != nil {
+			return errors.Wrap(err, "tr.write")
 		}
+	}
+
+	return nil
+}
 
-		buf.WriteString(trString)
+func (t *Table) columnsWidth(start int, span int) int {
+	var width int
+
+	for i := start; i < start+span && i < len(t.Grid); i++ {
+		width += t.Grid[i]
 	}
 
-	return buf.String(), nil
+	return width
 }
 
 func (t *Table) GetGrid() string {
@@ -1403,13 +1996,16 @@ func (t *Table) properties() string {
 
 	buf.WriteString("<w:tblPr>")
 	buf.WriteString(t.getStyleClass())
+	buf.WriteString(t.Position.properties())
 	buf.WriteString(t.getWidth())
 
-	horisontalAlign := "center"
-	if t.Style.HorisontalAlign != "" {
-		horisontalAlign = t.Style.HorisontalAlign
+	if t.Position == nil || t.Style.HorisontalAlign != "" {
+		horisontalAlign := "center"
+		if t.Style.HorisontalAlign != "" {
+			horisontalAlign = t.Style.HorisontalAlign
+		}
+		buf.WriteString(`<w:jc w:val="` + horisontalAlign + `" />`)
 	}
-	buf.WriteString(`<w:jc w:val="` + horisontalAlign + `" />`)
 	buf.WriteString(`<w:tblInd w:type="dxa" w:w="0" />`)
 	buf.WriteString(`<w:tblLayout w:type="` + t.getType() + `" />`)
 
@@ -1430,6 +2026,54 @@ func (t *Table) properties() string {
 	return buf.String()
 }
 
+func (pos *TablePosition) properties() string {
+	if pos == nil {
+		return ""
+	}
+
+	pos.Margins.SetValueByDefault(0)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<w:tblpPr`)
+	buf.WriteString(` w:leftFromText="` + pos.Margins.Left.String() + `"`)
+	buf.WriteString(` w:rightFromText="` + pos.Margins.Right.String() + `"`)
+	buf.WriteString(` w:topFromText="` + pos.Margins.Top.String() + `"`)
+	buf.WriteString(` w:bottomFromText="` + pos.Margins.Bottom.String() + `"`)
+	buf.WriteString(` w:vertAnchor="` + tableAnchor(pos.VerticalAnchor) + `"`)
+	buf.WriteString(` w:horzAnchor="` + tableAnchor(pos.HorisontalAnchor) + `"`)
+
+	if pos.HorisontalAlign != "" {
+		buf.WriteString(` w:tblpXSpec="` + pos.HorisontalAlign + `"`)
+	} else {
+		buf.WriteString(` w:tblpX="` + strconv.Itoa(pos.X) + `"`)
+	}
+
+	if pos.VerticalAlign != "" {
+		buf.WriteString(` w:tblpYSpec="` + pos.VerticalAlign + `"`)
+	} else {
+		buf.WriteString(` w:tblpY="` + strconv.Itoa(pos.Y) + `"`)
+	}
+
+	buf.WriteString(`/>`)
+
+	if pos.NoOverlap {
+		buf.WriteString(`<w:tblOverlap w:val="never"/>`)
+	}
+
+	return buf.String()
+}
+
+func tableAnchor(anchor string) string {
+	switch anchor {
+	case TableAnchorMargin:
+		return TableAnchorMargin
+	case TableAnchorPage:
+		return TableAnchorPage
+	default:
+		return TableAnchorText
+	}
+}
+
 func (t *Table) getWidth() string {
 	var width int
 
@@ -1500,113 +2144,150 @@ func (d *Document) SetTable(table *Table) error {
 		return nil
 	}
 
-	tableString, err := table.string(d)
-	if err != nil {
-		return errors.Wrap(err, "table.string")
+	var buf bytes.Buffer
+
+	if err := table.write(&buf, d); err != nil {
+		return errors.Wrap(err, "table.write")
 	}
 
-	d.Buf.WriteString(tableString)
+	d.body().Write(buf.Bytes())
+
+	return nil
+}
+
+func (d *Document) setItems(items []interface{}) error {
+	for _, item := range items {
+		var buf bytes.Buffer
+
+		if err := writeContent(&buf, writeContentArgs{
+			content:  item,
+			document: d,
+		}); err != nil {
+			return errors.Wrap(err, "writeContent")
+		}
+
+		d.body().Write(buf.Bytes())
+	}
 
 	return nil
 }
 
 func (img *Image) Error() error {
-	if img.Width == 0 {
-		return errors.New("no img.Width")
+	if len(img.Bytes) == 0 {
+		return errors.New("no img.Bytes")
 	}
 
 	return nil
 }
 
-func (img *Image) string(d *Document) (string, error) {
+func (img *Image) write(w xmlWriter, d *Document) error {
 	if err := img.Error(); err != nil {
-		return "", err
+		return err
 	}
 
-	if err := img.populateSizes(); err != nil {
-		return "", errors.Wrap(err, "Image.populateSizes")
+	if err := img.prepareMedia(d); err != nil {
+		return errors.Wrap(err, "Image.prepareMedia")
 	}
 
-	id := len(d.images.content) + 1
+	if err := img.populateSizes(d); err != nil {
+		return errors.Wrap(err, "Image.populateSizes")
+	}
+
+	bucket := &d.images.content
 	relsIdPrefix := imagesID
 
-	if img.isMainPageHeader {
-		id = len(d.images.mainPageHeader) + 1
-		relsIdPrefix = "mainPageHeaderImageID"
-	} else if img.isMainPageFooter {
-		id = len(d.images.mainPageFooter) + 1
-		relsIdPrefix = "mainPageFooterImageID"
-	} else if img.isHeader {
-		id = len(d.images.header) + 1
-		relsIdPrefix = "fileHeaderImageID"
-	} else if img.isFooter {
-		id = len(d.images.footer) + 1
-		relsIdPrefix = "fileFooterImageID"
-	}
-
-	img.ID = id
-	img.RelsID = relsIdPrefix + strconv.Itoa(img.ID)
-	img.ContentType = http.DetectContentType(img.Bytes)
+	if d.currentPart != nil {
+		bucket = &d.currentPart.images
+		relsIdPrefix = d.currentPart.fileName + "ImageID"
+	}
+
+	img.Extension = filepath.Ext(img.media.name)
 	img.FileName = filepath.Base(img.FileName)
-	img.Extension = filepath.Ext(img.FileName)
 
-	nameWithoutExt := img.FileName[0 : len(img.FileName)-len(img.Extension)]
+	d.drawingsCount++
+	img.ID = d.drawingsCount
 
-	widthInEMU := dxaToEMU(int64(img.Width))
-	heightInEMU := dxaToEMU(int64(img.Height))
+	isNewRelation := true
 
-	var buf bytes.Buffer
-	buf.WriteString("<w:r>")
-	buf.WriteString(`<w:drawing>`)
-	buf.WriteString(img.getDisplayTag())
-	buf.WriteString(img.getAlign())
-	buf.WriteString(img.getWrap())
-	buf.WriteString(`<wp:extent cx="` + strconv.FormatInt(widthInEMU, 10) + `" cy="` + strconv.FormatInt(heightInEMU, 10) + `"/>`)
-	buf.WriteString(`<wp:effectExtent l="0" t="0" r="0" b="0"/>`)
-	buf.WriteString(`<wp:docPr id="` + strconv.Itoa(img.ID) + `" name="` + nameWithoutExt + `" descr=""></wp:docPr>`)
-	buf.WriteString(`<wp:cNvGraphicFramePr>`)
-	buf.WriteString(`<a:graphicFrameLocks xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" noChangeAspect="1"/>`)
-	buf.WriteString(`</wp:cNvGraphicFramePr>`)
-	buf.WriteString(`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
-	buf.WriteString(`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
-	buf.WriteString(`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
-	buf.WriteString(`<pic:nvPicPr>`)
-	buf.WriteString(`<pic:cNvPr id="` + strconv.Itoa(img.ID) + `" name="` + nameWithoutExt + `" descr=""></pic:cNvPr>`)
-	buf.WriteString(`<pic:cNvPicPr>`)
-	buf.WriteString(`<a:picLocks noChangeAspect="1" noChangeArrowheads="1"/>`)
-	buf.WriteString(`</pic:cNvPicPr>`)
-	buf.WriteString(`</pic:nvPicPr>`)
-	buf.WriteString(`<pic:blipFill>`)
-	buf.WriteString(`<a:blip r:embed="` + img.RelsID + `"/>`)
-	buf.WriteString(`<a:stretch>`)
-	buf.WriteString(`<a:fillRect />`)
-	buf.WriteString(`</a:stretch>`)
-	buf.WriteString(`</pic:blipFill>`)
-	buf.WriteString(`<pic:spPr bwMode="auto">`)
-	buf.WriteString(`<a:xfrm>`)
-	buf.WriteString(`<a:off x="0" y="0" />`)
-	buf.WriteString(`<a:ext cx="` + strconv.FormatInt(widthInEMU, 10) + `" cy="` + strconv.FormatInt(heightInEMU, 10) + `" />`)
-	buf.WriteString(`</a:xfrm>`)
-	buf.WriteString(`<a:prstGeom prst="rect">`)
-	buf.WriteString(`<a:avLst/>`)
-	buf.WriteString(`</a:prstGeom>`)
-	buf.WriteString(`</pic:spPr>`)
-	buf.WriteString(`</pic:pic>`)
-	buf.WriteString(`</a:graphicData>`)
-	buf.WriteString(`</a:graphic>`)
-	buf.WriteString(img.getDislayCloseTag())
-	buf.WriteString(`</w:drawing>`)
-	buf.WriteString("</w:r>")
-
-	if img.isHeader {
-		d.images.header = append(d.images.header, img)
-	} else if img.isFooter {
-		d.images.footer = append(d.images.footer, img)
-	} else {
-		d.images.content = append(d.images.content, img)
+	for _, i := range *bucket {
+		if i.media == img.media {
+			img.RelsID = i.RelsID
+			img.svgRelsID = i.svgRelsID
+			isNewRelation = false
+			break
+		}
 	}
 
-	return buf.String(), nil
+	if isNewRelation {
+		img.RelsID = relsIdPrefix + strconv.Itoa(len(*bucket)+1)
+		img.svgRelsID = img.RelsID + "svg"
+	}
+
+	nameWithoutExt := escapeAttr(strings.TrimSuffix(img.FileName, filepath.Ext(img.FileName)))
+
+	widthInEMU := img.widthEMU
+	heightInEMU := img.heightEMU
+
+	w.WriteString("<w:r>")
+	w.WriteString(`<w:drawing>`)
+	w.WriteString(img.getDisplayTag())
+	w.WriteString(img.getPosition())
+	w.WriteString(`<wp:extent cx="` + strconv.FormatInt(widthInEMU, 10) + `" cy="` + strconv.FormatInt(heightInEMU, 10) + `"/>`)
+	w.WriteString(`<wp:effectExtent l="0" t="0" r="0" b="0"/>`)
+	w.WriteString(img.getWrap())
+	w.WriteString(`<wp:docPr id="` + strconv.Itoa(img.ID) + `" name="` + nameWithoutExt + `"` + img.getAltText() + `>`)
+
+	if img.IsDecorative {
+		w.WriteString(`<a:extLst xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+		w.WriteString(`<a:ext uri="{C183D7F6-B498-43B3-948B-1728B52AA6E4}">`)
+		w.WriteString(`<adec:decorative xmlns:adec="http://schemas.microsoft.com/office/drawing/2017/decorative" val="1"/>`)
+		w.WriteString(`</a:ext>`)
+		w.WriteString(`</a:extLst>`)
+	}
+
+	w.WriteString(`</wp:docPr>`)
+	w.WriteString(`<wp:cNvGraphicFramePr>`)
+	w.WriteString(`<a:graphicFrameLocks xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" noChangeAspect="1"/>`)
+	w.WriteString(`</wp:cNvGraphicFramePr>`)
+	w.WriteString(`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+	w.WriteString(`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+	w.WriteString(`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`)
+	w.WriteString(`<pic:nvPicPr>`)
+	w.WriteString(`<pic:cNvPr id="` + strconv.Itoa(img.ID) + `" name="` + nameWithoutExt + `"` + img.getAltText() + `></pic:cNvPr>`)
+	w.WriteString(`<pic:cNvPicPr>`)
+	w.WriteString(`<a:picLocks noChangeAspect="1" noChangeArrowheads="1"/>`)
+	w.WriteString(`</pic:cNvPicPr>`)
+	w.WriteString(`</pic:nvPicPr>`)
+	w.WriteString(`<pic:blipFill>`)
+	w.WriteString(img.getBlip())
+	w.WriteString(img.Crop.srcRect())
+	w.WriteString(`<a:stretch>`)
+	w.WriteString(`<a:fillRect />`)
+	w.WriteString(`</a:stretch>`)
+	w.WriteString(`</pic:blipFill>`)
+	w.WriteString(`<pic:spPr bwMode="auto">`)
+	w.WriteString(`<a:xfrm` + img.getTransform() + `>`)
+	w.WriteString(`<a:off x="0" y="0" />`)
+	w.WriteString(`<a:ext cx="` + strconv.FormatInt(widthInEMU, 10) + `" cy="` + strconv.FormatInt(heightInEMU, 10) + `" />`)
+	w.WriteString(`</a:xfrm>`)
+	w.WriteString(`<a:prstGeom prst="` + img.getShape() + `">`)
+	w.WriteString(`<a:avLst/>`)
+	w.WriteString(`</a:prstGeom>`)
+	w.WriteString(img.getOutline())
+	w.WriteString(img.Shadow.effects())
+	w.WriteString(`</pic:spPr>`)
+	w.WriteString(`</pic:pic>`)
+	w.WriteString(`</a:graphicData>`)
+	w.WriteString(`</a:graphic>`)
+	w.WriteString(img.getDislayCloseTag())
+	w.WriteString(`</w:drawing>`)
+	w.WriteString("</w:r>")
+
+	if isNewRelation {
+		*bucket = append(*bucket, img)
+	}
+
+	return nil
 }
 
 func (img *Image) getDislayCloseTag() string {
@@ -1617,46 +2298,306 @@ func (img *Image) getDislayCloseTag() string {
 	return `</wp:inline>`
 }
 
-func (img *Image) populateSizes() error {
-	reader := bytes.NewReader(img.Bytes)
+func (img *Image) prepareMedia(d *Document) error {
+	img.ContentType = detectImageContentType(img.Bytes)
+	img.svgMedia = nil
+
+	data := img.Bytes
+	contentType := img.ContentType
+
+	switch img.ContentType {
+	case contentTypeWebP:
+		converted, err := convertToPNG(img.Bytes)
+		if err != nil {
+			return errors.Wrap(err, "convertToPNG")
+		}
+
+		data = converted
+		contentType = contentTypePNG
+
+	case contentTypeSVG:
+		fallback, err := svgToPNG(img.Bytes)
+		if err != nil {
+			return errors.Wrap(err, "svgToPNG")
+		}
+
+		img.svgMedia = d.addMedia(img.Bytes, contentTypeSVG, img.FileName)
+		data = fallback
+		contentType = contentTypePNG
+	}
+
+	if !isContentTypeValid(contentType) {
+		return errors.New("unsupported image type " + img.ContentType)
+	}
+
+	img.media = d.addMedia(data, contentType, img.FileName)
+
+	return nil
+}
+
+func (img *Image) getAltText() string {
+	if img.IsDecorative {
+		return ` descr=""`
+	}
+
+	altText := ` descr="` + escapeAttr(img.Description) + `"`
+
+	if img.Title != "" {
+		altText += ` title="` + escapeAttr(img.Title) + `"`
+	}
+
+	return altText
+}
+
+func (crop *ImageCrop) srcRect() string {
+	if crop == nil {
+		return ""
+	}
+
+	return `<a:srcRect l="` + percentToDrawing(crop.Left) + `" t="` + percentToDrawing(crop.Top) + `" r="` + percentToDrawing(crop.Right) + `" b="` + percentToDrawing(crop.Bottom) + `"/>`
+}
+
+func (img *Image) getTransform() string {
+	var buf bytes.Buffer
+
+	if img.Rotation != 0 {
+		buf.WriteString(` rot="` + strconv.Itoa(degreesToDrawing(img.Rotation)) + `"`)
+	}
+
+	if img.FlipHorisontal {
+		buf.WriteString(` flipH="1"`)
+	}
+
+	if img.FlipVertical {
+		buf.WriteString(` flipV="1"`)
+	}
+
+	return buf.String()
+}
+
+func (img *Image) getShape() string {
+	if img.Shape == "" {
+		return ImageShapeRect
+	}
+
+	return img.Shape
+}
+
+func (img *Image) getOutline() string {
+	if img.Outline == nil || img.Outline.Width == 0 {
+		return ""
+	}
+
+	color := "000000"
+	if img.Outline.Color != "" {
+		color = img.Outline.Color
+	}
+
+	var buf bytes.Buffer
+	// border width is set in eighths of a point, 12700 EMU per point
+	buf.WriteString(`<a:ln w="` + strconv.Itoa(img.Outline.Width*12700/8) + `">`)
+	buf.WriteString(`<a:solidFill><a:srgbClr val="` + color + `"/></a:solidFill>`)
+	buf.WriteString(`<a:prstDash val="` + drawingDash(img.Outline.Type) + `"/>`)
+	buf.WriteString(`</a:ln>`)
+
+	return buf.String()
+}
+
+func drawingDash(borderType string) string {
+	switch borderType {
+	case BorderDotted:
+		return "sysDot"
+	case BorderDashed:
+		return "dash"
+	case BorderDashSmallGap:
+		return "sysDash"
+	default:
+		return "solid"
+	}
+}
+
+func (shadow *ImageShadow) effects() string {
+	if shadow == nil {
+		return ""
+	}
+
+	color := "000000"
+	if shadow.Color != "" {
+		color = shadow.Color
+	}
+
+	blur := 80
+	if shadow.Blur != 0 {
+		blur = shadow.Blur
+	}
+
+	distance := 60
+	if shadow.Distance != 0 {
+		distance = shadow.Distance
+	}
+
+	angle := 45.0
+	if shadow.Angle != 0 {
+		angle = shadow.Angle
+	}
+
+	opacity := 40
+	if shadow.Opacity != 0 {
+		opacity = shadow.Opacity
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<a:effectLst>`)
+	buf.WriteString(`<a:outerShdw blurRad="` + strconv.FormatInt(dxaToEMU(int64(blur)), 10) + `" dist="` + strconv.FormatInt(dxaToEMU(int64(distance)), 10) + `" dir="` + strconv.Itoa(degreesToDrawing(angle)) + `" algn="tl" rotWithShape="0">`)
+	buf.WriteString(`<a:srgbClr val="` + color + `"><a:alpha val="` + strconv.Itoa(opacity*1000) + `"/></a:srgbClr>`)
+	buf.WriteString(`</a:outerShdw>`)
+	buf.WriteString(`</a:effectLst>`)
+
+	return buf.String()
+}
+
+func percentToDrawing(value float64) string {
+	return strconv.Itoa(int(math.Round(value * 1000)))
+}
+
+func degreesToDrawing(value float64) int {
+	value = math.Mod(value, 360)
+	if value < 0 {
+		value += 360
+	}
+
+	return int(math.Round(value * 60000))
+}
+
+func (img *Image) getBlip() string {
+	if img.svgMedia == nil {
+		return `<a:blip r:embed="` + img.RelsID + `"/>`
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<a:blip r:embed="` + img.RelsID + `">`)
+	buf.WriteString(`<a:extLst>`)
+	buf.WriteString(`<a:ext uri="{96DAC541-7B7A-43D3-8B79-37D633B846F1}">`)
+	buf.WriteString(`<asvg:svgBlip xmlns:asvg="http://schemas.microsoft.com/office/drawing/2016/SVG/main" r:embed="` + img.svgRelsID + `"/>`)
+	buf.WriteString(`</a:ext>`)
+	buf.WriteString(`</a:extLst>`)
+	buf.WriteString(`</a:blip>`)
+
+	return buf.String()
+}
+
+func (img *Image) populateSizes(d *Document) error {
+	reader := bytes.NewReader(img.media.bytes)
 	config, _, err := image.DecodeConfig(reader)
 	if err != nil {
 		return errors.Wrap(err, "image.DecodeConfig")
 	}
 
-	if img.Width == 0 {
-		img.Width = int64(float64(img.Height) * float64(config.Width) / float64(config.Height))
+	if config.Width == 0 || config.Height == 0 {
+		return errors.New("image has no size")
+	}
+
+	dpiX, dpiY := imageDPI(img.media.bytes, img.media.contentType)
+
+	naturalWidth := float64(config.Width) / dpiX * emuPerInch
+	naturalHeight := float64(config.Height) / dpiY * emuPerInch
+
+	dpi := float64(img.DPI)
+	if dpi == 0 {
+		dpi = defaultDPI
+	}
+
+	width := float64(sizeToEMU(img.Width, img.SizeUnit, dpi))
+	height := float64(sizeToEMU(img.Height, img.SizeUnit, dpi))
+
+	if img.WidthPercent > 0 {
+		width = float64(dxaToEMU(int64(d.containerWidth()))) * img.WidthPercent / 100
+	}
+
+	switch {
+	case width == 0 && height == 0:
+		width = naturalWidth
+		height = naturalHeight
+	case width == 0:
+		width = height * naturalWidth / naturalHeight
+	case height == 0:
+		height = width * naturalHeight / naturalWidth
+	}
+
+	maxWidth := float64(sizeToEMU(img.MaxWidth, img.SizeUnit, dpi))
+	maxHeight := float64(sizeToEMU(img.MaxHeight, img.SizeUnit, dpi))
+	scale := 1.0
+
+	if img.MaxWidthPercent > 0 {
+		maxWidth = float64(dxaToEMU(int64(d.containerWidth()))) * img.MaxWidthPercent / 100
+	}
+
+	if maxWidth > 0 && width > maxWidth {
+		scale = maxWidth / width
 	}
 
-	if img.Height == 0 {
-		img.Height = int64(float64(img.Width) * float64(config.Height) / float64(config.Width))
+	if maxHeight > 0 && height*scale > maxHeight {
+		scale = maxHeight / height
 	}
 
+	img.widthEMU = int64(math.Round(width * scale))
+	img.heightEMU = int64(math.Round(height * scale))
+
 	return nil
 }
 
-func (img *Image) getAlign() string {
-	if img.HorisontalAlign == "" && img.VerticalAlign == "" {
+func sizeToEMU(value int64, unit string, dpi float64) int64 {
+	switch unit {
+	case SizeUnitMM:
+		return int64(mmToEMU(int(value)))
+	case SizeUnitCM:
+		return int64(mmToEMU(int(value * 10)))
+	case SizeUnitInch:
+		return value * emuPerInch
+	case SizeUnitPoint:
+		return value * emuPerPoint
+	case SizeUnitPixel:
+		return int64(math.Round(float64(value) / dpi * emuPerInch))
+	default:
+		return dxaToEMU(value)
+	}
+}
+
+func (img *Image) getPosition() string {
+	if img.Display != ImageDisplayFloat {
 		return ""
 	}
 
 	var buf bytes.Buffer
 
-	if img.HorisontalAlign != "" {
-		buf.WriteString(`<wp:positionH relativeFrom="` + img.getHorisontalAnchor() + `">`)
-		buf.WriteString(`<wp:align>` + img.HorisontalAlign + `</wp:align>`)
-		buf.WriteString(`</wp:positionH>`)
+	if img.SimplePosition != nil {
+		buf.WriteString(`<wp:simplePos x="` + strconv.FormatInt(dxaToEMU(int64(img.SimplePosition.X)), 10) + `" y="` + strconv.FormatInt(dxaToEMU(int64(img.SimplePosition.Y)), 10) + `"/>`)
+	} else {
+		buf.WriteString(`<wp:simplePos x="0" y="0"/>`)
 	}
 
-	if img.VerticalAlign != "" {
-		buf.WriteString(`<wp:positionV relativeFrom="` + img.getVerticalAnchor() + `">`)
-		buf.WriteString(`<wp:align>` + img.VerticalAlign + `</wp:align>`)
-		buf.WriteString(`</wp:positionV>`)
-	}
+	buf.WriteString(`<wp:positionH relativeFrom="` + img.getHorisontalAnchor() + `">`)
+	buf.WriteString(positionValue(img.HorisontalAlign, img.OffsetX))
+	buf.WriteString(`</wp:positionH>`)
+	buf.WriteString(`<wp:positionV relativeFrom="` + img.getVerticalAnchor() + `">`)
+	buf.WriteString(positionValue(img.VerticalAlign, img.OffsetY))
+	buf.WriteString(`</wp:positionV>`)
 
 	return buf.String()
 }
 
+func positionValue(align string, offset *Margin) string {
+	if offset == nil && align != "" {
+		return `<wp:align>` + align + `</wp:align>`
+	}
+
+	if offset == nil {
+		offset = &Margin{Value: 0}
+	}
+
+	return `<wp:posOffset>` + offset.emuString() + `</wp:posOffset>`
+}
+
 func (img *Image) getVerticalAnchor() string {
 	switch img.VerticalAnchor {
 	case "":
@@ -1729,27 +2670,74 @@ func (img *Image) getDisplayTag() string {
 	img.setMarginMaybe()
 
 	if img.Display == ImageDisplayFloat {
-		isRelative := "0"
-		if img.IsRelative {
-			isRelative = "1"
-		}
+		behindDoc := img.IsRelative || img.Wrap == ImageWrapBehind
 
-		return `<wp:anchor behindDoc="` + isRelative + `" distT="` + img.MarginTop.emuString() + `" distB="` + img.MarginBottom.emuString() + `" distL="` + img.MarginLeft.emuString() + `" distR="` + img.MarginRight.emuString() + `" simplePos="0" locked="1" layoutInCell="0" allowOverlap="1" relativeHeight="` + strconv.Itoa(img.ZIndex) + `">`
+		return `<wp:anchor behindDoc="` + boolToOnOff(behindDoc) + `" distT="` + img.MarginTop.emuString() + `" distB="` + img.MarginBottom.emuString() + `" distL="` + img.MarginLeft.emuString() + `" distR="` + img.MarginRight.emuString() + `" simplePos="` + boolToOnOff(img.SimplePosition != nil) + `" locked="` + boolToOnOff(!img.Unlocked) + `" layoutInCell="` + boolToOnOff(img.LayoutInCell) + `" allowOverlap="` + boolToOnOff(!img.NoOverlap) + `" relativeHeight="` + strconv.Itoa(img.ZIndex) + `">`
 	}
 
 	return `<wp:inline distT="` + img.MarginTop.emuString() + `" distB="` + img.MarginBottom.emuString() + `" distL="` + img.MarginLeft.emuString() + `" distR="` + img.MarginRight.emuString() + `">`
 }
 
 func (img *Image) getWrap() string {
+	if img.Display != ImageDisplayFloat {
+		return ""
+	}
+
 	if img.IsBackground {
 		return "<wp:wrapNone/>"
 	}
 
-	if img.Display == ImageDisplayFloat {
-		return `<wp:wrapSquare wrapText="largest" distT="0" distB="0" distL="0" distR="0" />`
+	switch img.Wrap {
+	case ImageWrapBehind, ImageWrapInFront:
+		return "<wp:wrapNone/>"
+	case ImageWrapTopAndBottom:
+		return `<wp:wrapTopAndBottom distT="` + img.MarginTop.emuString() + `" distB="` + img.MarginBottom.emuString() + `"/>`
+	case ImageWrapTight, ImageWrapThrough:
+		tag := "wp:wrapTight"
+		if img.Wrap == ImageWrapThrough {
+			tag = "wp:wrapThrough"
+		}
+
+		var buf bytes.Buffer
+
+		buf.WriteString(`<` + tag + ` wrapText="` + img.getWrapText() + `" distL="` + img.MarginLeft.emuString() + `" distR="` + img.MarginRight.emuString() + `">`)
+		buf.WriteString(`<wp:wrapPolygon edited="0">`)
+		buf.WriteString(`<wp:start x="0" y="0"/>`)
+		buf.WriteString(`<wp:lineTo x="0" y="` + wrapPolygonSize + `"/>`)
+		buf.WriteString(`<wp:lineTo x="` + wrapPolygonSize + `" y="` + wrapPolygonSize + `"/>`)
+		buf.WriteString(`<wp:lineTo x="` + wrapPolygonSize + `" y="0"/>`)
+		buf.WriteString(`<wp:lineTo x="0" y="0"/>`)
+		buf.WriteString(`</wp:wrapPolygon>`)
+		buf.WriteString(`</` + tag + `>`)
+
+		return buf.String()
+	default:
+		return `<wp:wrapSquare wrapText="` + img.getWrapText() + `" distT="` + img.MarginTop.emuString() + `" distB="` + img.MarginBottom.emuString() + `" distL="` + img.MarginLeft.emuString() + `" distR="` + img.MarginRight.emuString() + `" />`
 	}
+}
+
+func (img *Image) getWrapText() string {
+	switch img.WrapText {
+	case WrapTextBothSides, WrapTextLeft, WrapTextRight:
+		return img.WrapText
+	default:
+		return WrapTextLargest
+	}
+}
 
-	return ""
+func boolToOnOff(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
+
+func escapeAttr(value string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+
+	return buf.String()
 }
 
 func dxaToEMU(value int64) int64 {
@@ -1763,33 +2751,59 @@ func mmToEMU(mm int) int {
 }
 
 func (d *Document) SetPageBreak() {
-	d.Buf.WriteString(`<w:p>`)
-	d.Buf.WriteString(`<w:pPr>`)
-	d.Buf.WriteString(`<w:pStyle w:val="Normal"/>`)
-	d.Buf.WriteString(`<w:rPr></w:rPr>`)
-	d.Buf.WriteString(`</w:pPr>`)
-	d.Buf.WriteString(`<w:r>`)
-	d.Buf.WriteString(`<w:rPr></w:rPr>`)
-	d.Buf.WriteString(`</w:r>`)
-	d.Buf.WriteString(`<w:r>`)
-	d.Buf.WriteString(`<w:br w:type="page"/>`)
-	d.Buf.WriteString(`</w:r>`)
-	d.Buf.WriteString(`</w:p>`)
+	d.body().WriteString(`<w:p>`)
+	d.body().WriteString(`<w:pPr>`)
+	d.body().WriteString(`<w:pStyle w:val="Normal"/>`)
+	d.body().WriteString(`<w:rPr></w:rPr>`)
+	d.body().WriteString(`</w:pPr>`)
+	d.body().WriteString(`<w:r>`)
+	d.body().WriteString(`<w:rPr></w:rPr>`)
+	d.body().WriteString(`</w:r>`)
+	d.body().WriteString(`<w:r>`)
+	d.body().WriteString(`<w:br w:type="page"/>`)
+	d.body().WriteString(`</w:r>`)
+	d.body().WriteString(`</w:p>`)
 }
 
 type Section struct {
-	Type            string
-	PageOrientation string
-	Margins         *Margins
+	Type             string
+	PageOrientation  string
+	PageSize         *PageSize
+	Margins          *Margins
+	Header           []*Paragraph
+	Footer           []*Paragraph
+	FirstPageHeader  []*Paragraph
+	FirstPageFooter  []*Paragraph
+	EvenHeader       []*Paragraph
+	EvenFooter       []*Paragraph
+	LinkToPrevious   bool
+	FooterPageNumber bool
+	PageNumberFormat string
+	PageNumberStart  int
+	FootnoteOptions  *NoteOptions
+	EndnoteOptions   *NoteOptions
+}
+
+// BeginSection sets the page of the content that follows until SetSection,
+// so percent widths and GetInnerWidth use the page size, orientation and
+// margins of the section instead of the document ones. Word keeps section
+// properties at the end of a section, SetSection still writes them.
+func (d *Document) BeginSection(section *Section) {
+	d.section = section
 }
 
 func (d *Document) SetSection(section *Section) error {
-	d.Buf.WriteString(section.string(d))
+	if err := notesError(section.FootnoteOptions, section.EndnoteOptions); err != nil {
+		return err
+	}
+
+	section.write(d.body(), d)
+	d.section = nil
 
 	return nil
 }
 
-func (section *Section) string(d *Document) string {
+func (section *Section) write(w xmlWriter, d *Document) {
 	if section.Type == "" {
 		section.Type = SectionTypeContinious
 	}
@@ -1798,20 +2812,46 @@ func (section *Section) string(d *Document) string {
 		section.PageOrientation = d.PageOrientation
 	}
 
+	if section.PageSize == nil {
+		section.PageSize = d.getPageSize()
+	}
+
 	if section.Margins == nil {
 		section.Margins = &d.Margins
 	}
 
-	var buf bytes.Buffer
-	buf.WriteString(`<w:p>`)
-	buf.WriteString(`<w:pPr>`)
-	buf.WriteString(`<w:sectPr>`)
-	buf.WriteString(`<w:type w:val="` + section.Type + `"/>`)
-	buf.WriteString(sectionSizes(section.PageOrientation))
-	buf.WriteString(sectionMargins(*section.Margins))
-	buf.WriteString(`</w:sectPr>`)
-	buf.WriteString(`</w:pPr>`)
-	buf.WriteString(`</w:p>`)
+	w.WriteString(`<w:p>`)
+	w.WriteString(`<w:pPr>`)
+	w.WriteString(`<w:sectPr>`)
+
+	hasFirstPage := d.writeHeaderFooterReferences(w, writeHeaderFooterReferencesArgs{
+		slots: []headerFooterSlot{
+			{tag: headerTag, reference: headerReferenceDefault, content: section.Header},
+			{tag: headerTag, reference: headerReferenceFirst, content: section.FirstPageHeader},
+			{tag: headerTag, reference: headerReferenceEven, content: section.EvenHeader},
+			{tag: footerTag, reference: headerReferenceDefault, content: section.Footer},
+			{tag: footerTag, reference: headerReferenceFirst, content: section.FirstPageFooter},
+			{tag: footerTag, reference: headerReferenceEven, content: section.EvenFooter},
+		},
+		linkToPrevious: section.LinkToPrevious,
+		pageNumber:     section.FooterPageNumber,
+		width:          innerWidth(section.PageSize, section.PageOrientation, *section.Margins),
+	})
 
-	return buf.String()
+	w.WriteString(notesProperties(section.FootnoteOptions, section.EndnoteOptions))
+	w.WriteString(`<w:type w:val="` + section.Type + `"/>`)
+	w.WriteString(sectionSizes(section.PageSize, section.PageOrientation))
+	w.WriteString(sectionMargins(*section.Margins))
+
+	if section.PageNumberFormat != "" || section.PageNumberStart > 0 {
+		w.WriteString(pageNumberType(section.PageNumberFormat, section.PageNumberStart))
+	}
+
+	if hasFirstPage {
+		w.WriteString(`<w:titlePg/>`)
+	}
+
+	w.WriteString(`</w:sectPr>`)
+	w.WriteString(`</w:pPr>`)
+	w.WriteString(`</w:p>`)
 }
@@ -0,0 +1,136 @@
+package zdocx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageSizePresets(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	doc.PageSize = PageSizeA4()
+	doc.PageSize.Width = 1000
+
+	if PageSizeA4().Width != 11906 {
+		t.Error("changing a document page size changed the preset")
+	}
+
+	if NewDocument(NewDocumentArgs{}).getPageSize() == NewDocument(NewDocumentArgs{}).getPageSize() {
+		t.Error("documents share the default page size")
+	}
+
+	if size := CustomPageSize(100, 150, SizeUnitMM); size.Width != 5669 || size.Height != 8504 {
+		t.Errorf("custom page size %d×%d", size.Width, size.Height)
+	}
+}
+
+func TestPageOrientation(t *testing.T) {
+	for orientation, expected := range map[string]string{
+		PageOrientationPortrait:  `<w:pgSz w:w="11906" w:h="16838" w:code="9" />`,
+		PageOrientationBook:      `<w:pgSz w:w="11906" w:h="16838" w:code="9" />`,
+		PageOrientationLandscape: `<w:pgSz w:w="16838" w:h="11906" w:orient="landscape" w:code="9" />`,
+		PageOrientationAlbum:     `<w:pgSz w:w="16838" w:h="11906" w:orient="landscape" w:code="9" />`,
+	} {
+		if sizes := sectionSizes(PageSizeA4(), orientation); sizes != expected {
+			t.Errorf("%s: %s", orientation, sizes)
+		}
+	}
+}
+
+func TestBeginSectionWidth(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{})
+	doc.PageSize = PageSizeA4()
+	portrait := doc.GetInnerWidth()
+
+	landscape := &Section{PageOrientation: PageOrientationLandscape}
+	doc.BeginSection(landscape)
+
+	if width := doc.GetInnerWidth(); width != 16838-2*DocumentDefaultMargin {
+		t.Errorf("landscape section inner width %d", width)
+	}
+
+	img := &Image{Bytes: testPNG(t, 10, 10), WidthPercent: 100}
+
+	if err := doc.SetP(&Paragraph{Texts: []*Text{{Image: img}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if img.widthEMU != dxaToEMU(int64(16838-2*DocumentDefaultMargin)) {
+		t.Errorf("image in the landscape section is %d EMU wide", img.widthEMU)
+	}
+
+	if err := doc.SetSection(landscape); err != nil {
+		t.Fatal(err)
+	}
+
+	if width := doc.GetInnerWidth(); width != portrait {
+		t.Errorf("inner width after the section %d, expected %d", width, portrait)
+	}
+
+	if !strings.Contains(doc.String(), `<w:pgSz w:w="16838" w:h="11906" w:orient="landscape" w:code="9" />`) {
+		t.Error("no landscape section properties")
+	}
+}
+
+func TestDocumentLinkToPrevious(t *testing.T) {
+	for _, link := range []bool{false, true} {
+		doc := NewDocument(NewDocumentArgs{})
+		doc.LinkToPrevious = link
+
+		if err := doc.SetSection(&Section{
+			Type:   SectionTypeNextPage,
+			Header: []*Paragraph{{Texts: []*Text{{Text: "first section"}}}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := doc.WriteToBuffer()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := string(zipEntries(t, data.Bytes())["word/document.xml"])
+		last := body[strings.LastIndex(body, "<w:sectPr>"):]
+		referenced := strings.Contains(last, `<w:headerReference w:type="default"`)
+
+		if referenced == link {
+			t.Errorf("LinkToPrevious %v, final section references a header: %v", link, referenced)
+		}
+	}
+}
+
+func TestDocumentGutterAndMirrorMargins(t *testing.T) {
+	doc := NewDocument(NewDocumentArgs{Margins: &Margins{Gutter: &Margin{Value: 567}}})
+	doc.PageSize = PageSizeA4()
+	doc.MirrorMargins = true
+	doc.EvenHeader = []*Paragraph{{Texts: []*Text{{Text: "even"}}}}
+
+	if width := doc.GetInnerWidth(); width != 11906-2*DocumentDefaultMargin-567 {
+		t.Errorf("inner width %d", width)
+	}
+
+	data, err := doc.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := zipEntries(t, data.Bytes())
+	settings := string(entries["word/settings.xml"])
+	body := string(entries["word/document.xml"])
+	sectPr := body[strings.LastIndex(body, "<w:sectPr>"):]
+
+	for _, expected := range []string{`<w:mirrorMargins/>`, `<w:evenAndOddHeaders/>`} {
+		if !strings.Contains(settings, expected) {
+			t.Errorf("no %s in the settings", expected)
+		}
+	}
+
+	for _, expected := range []string{`w:gutter="567"`, `<w:headerReference w:type="even"`} {
+		if !strings.Contains(sectPr, expected) {
+			t.Errorf("no %s in the section properties", expected)
+		}
+	}
+
+	if margins := (Margins{Gutter: &Margin{}}); margins.IsEmpty() {
+		t.Error("margins with a gutter are empty")
+	}
+}